@@ -1,12 +1,19 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"log/slog"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
 	"github.com/markormesher/tedium/internal/entrypoints"
+	"github.com/markormesher/tedium/internal/metrics"
 	"github.com/markormesher/tedium/internal/schema"
+	"github.com/markormesher/tedium/internal/tracing"
 )
 
 var version string // populated via ldflags
@@ -14,9 +21,18 @@ var version string // populated via ldflags
 func main() {
 	slog.Info("tedium version: " + version)
 
-	internalCommand := flag.String("internal-command", "", "Internal command to perform when Tedium is running itself inside an executor")
-	configFilePath := flag.String("config", "", "Path to configuration file")
-	flag.Parse()
+	// the first argument may be a sub-command; without one Tedium performs a single run
+	command := "run"
+	args := os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command = args[0]
+		args = args[1:]
+	}
+
+	flags := flag.NewFlagSet("tedium "+command, flag.ExitOnError)
+	internalCommand := flags.String("internal-command", "", "Internal command to perform when Tedium is running itself inside an executor")
+	configFilePath := flags.String("config", "", "Path to configuration file")
+	_ = flags.Parse(args)
 
 	// special cases: internal commands
 	switch *internalCommand {
@@ -29,6 +45,15 @@ func main() {
 		return
 	}
 
+	// special case: validation doesn't need a runtime config and must not contact any platform
+	if command == "validate" {
+		err := entrypoints.Validate(*configFilePath, flags.Args())
+		if err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+
 	// normal case: user invocation
 	if *configFilePath == "" {
 		slog.Error("config file not provided")
@@ -41,5 +66,41 @@ func main() {
 		os.Exit(1)
 	}
 
-	entrypoints.Run(conf)
+	// cancelled when we are asked to stop (e.g. when the pod running Tedium is evicted)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Init(ctx, conf)
+	if err != nil {
+		slog.Error("error initialising tracing", "error", err)
+		os.Exit(1)
+	}
+
+	switch command {
+	case "run":
+		err = entrypoints.Run(ctx, conf, entrypoints.RunOptions{})
+
+		if conf.Metrics.PushgatewayURL != "" {
+			pushErr := metrics.Push(conf.Metrics.PushgatewayURL)
+			if pushErr != nil {
+				slog.Error("error pushing metrics", "error", pushErr)
+			}
+		}
+
+	case "serve":
+		err = entrypoints.Serve(ctx, conf, *configFilePath, version)
+
+	default:
+		err = fmt.Errorf("unknown command: %s", command)
+	}
+
+	// flush spans before exiting, because os.Exit skips deferred calls
+	shutdownTracing()
+
+	// a run that was stopped early (e.g. by SIGTERM) is a failure, because some chores didn't finish; the daemon returns nil when it is asked to stop
+	if err != nil {
+		slog.Error("tedium failed", "error", err)
+		stop()
+		os.Exit(1)
+	}
 }
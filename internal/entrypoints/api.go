@@ -0,0 +1,246 @@
+package entrypoints
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"html/template"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/markormesher/tedium/internal/schema"
+)
+
+// triggerRequest is the body accepted when triggering a run via the API. Both fields are optional; without them every chore for every repo is run.
+type triggerRequest struct {
+	// Repo is either a repo URL or an "owner/name" string.
+	Repo string `json:"repo"`
+
+	// Chore is the name of a chore.
+	Chore string `json:"chore"`
+}
+
+type triggerResponse struct {
+	RunID string `json:"runID"`
+}
+
+func (d *daemon) registerAPI(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/runs", d.requireStatusAccess(d.handleListRuns))
+	mux.HandleFunc("GET /api/runs/{id}", d.requireStatusAccess(d.handleGetRun))
+	mux.HandleFunc("POST /api/runs", d.requireToken(d.handleTriggerRun))
+
+	mux.HandleFunc("GET /{$}", d.requireStatusAccess(d.handleRunsPage))
+	mux.HandleFunc("GET /runs/{id}", d.requireStatusAccess(d.handleRunPage))
+}
+
+// requireStatusAccess protects read-only endpoints, which include chore logs, unless they have been made public.
+func (d *daemon) requireStatusAccess(handler http.HandlerFunc) http.HandlerFunc {
+	protected := d.requireToken(handler)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if d.currentConfig().Daemon.PublicStatus {
+			handler(w, r)
+			return
+		}
+
+		protected(w, r)
+	}
+}
+
+// requireToken only calls the handler if the request carries the API token. Without a configured token the endpoint is disabled.
+func (d *daemon) requireToken(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, err := d.currentConfig().Daemon.ReadAPIToken()
+		if err != nil {
+			slog.Error("error reading API token", "error", err)
+			http.Error(w, "error reading API token", http.StatusInternalServerError)
+			return
+		}
+
+		if token == "" {
+			http.Error(w, "this endpoint is disabled because no API token is configured", http.StatusForbidden)
+			return
+		}
+
+		providedToken, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(providedToken), []byte(token)) != 1 {
+			http.Error(w, "invalid API token", http.StatusUnauthorized)
+			return
+		}
+
+		handler(w, r)
+	}
+}
+
+func (d *daemon) handleListRuns(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, d.runs.list())
+}
+
+func (d *daemon) handleGetRun(w http.ResponseWriter, r *http.Request) {
+	run, ok := d.runs.get(r.PathValue("id"))
+	if !ok {
+		http.Error(w, "run not found", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, run)
+}
+
+func (d *daemon) handleTriggerRun(w http.ResponseWriter, r *http.Request) {
+	var req triggerRequest
+	err := json.NewDecoder(io.LimitReader(r.Body, 64*1024)).Decode(&req)
+	if err != nil && err != io.EOF {
+		http.Error(w, "error parsing body", http.StatusBadRequest)
+		return
+	}
+
+	opts := RunOptions{}
+
+	if req.Repo != "" {
+		if strings.Contains(req.Repo, "://") {
+			wantedKey := d.repoKey(req.Repo)
+			opts.RepoFilter = func(repo schema.Repo) bool {
+				return d.repoKey(repo.CloneURL) == wantedKey
+			}
+		} else {
+			opts.RepoFilter = func(repo schema.Repo) bool {
+				return strings.EqualFold(repo.FullName(), req.Repo)
+			}
+		}
+	}
+
+	if req.Chore != "" {
+		opts.ChoreFilter = func(_ schema.Repo, chore schema.ChoreSpec) bool {
+			return chore.Name == req.Chore
+		}
+	}
+
+	runID, _ := d.queueRun(runTriggerAPI, opts)
+	slog.Info("run triggered via API", "runID", runID, "repo", req.Repo, "chore", req.Chore)
+
+	writeJSON(w, http.StatusAccepted, triggerResponse{RunID: runID})
+}
+
+func (d *daemon) handleRunsPage(w http.ResponseWriter, _ *http.Request) {
+	renderPage(w, runsPageTemplate, d.runs.list())
+}
+
+func (d *daemon) handleRunPage(w http.ResponseWriter, r *http.Request) {
+	run, ok := d.runs.get(r.PathValue("id"))
+	if !ok {
+		http.Error(w, "run not found", http.StatusNotFound)
+		return
+	}
+
+	renderPage(w, runPageTemplate, run)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	err := json.NewEncoder(w).Encode(body)
+	if err != nil {
+		slog.Warn("error writing response", "error", err)
+	}
+}
+
+func renderPage(w http.ResponseWriter, tmpl *template.Template, data any) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	err := tmpl.ExecuteTemplate(w, "page", data)
+	if err != nil {
+		slog.Warn("error rendering page", "error", err)
+	}
+}
+
+var pageFuncs = template.FuncMap{
+	"duration": func(d time.Duration) string {
+		return d.Round(time.Second).String()
+	},
+	"time": func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+		return t.Format(time.DateTime)
+	},
+}
+
+const pageLayout = `
+{{define "page"}}<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>Tedium</title>
+  <style>
+    body { font-family: sans-serif; margin: 2em; }
+    table { border-collapse: collapse; }
+    th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+    pre { max-width: 80em; overflow-x: auto; background: #f4f4f4; padding: 0.5em; }
+    .succeeded { color: #080; } .failed, .timedOut { color: #b00; } .running, .queued { color: #06c; }
+  </style>
+</head>
+<body>
+  <h1><a href="/">Tedium</a></h1>
+  {{template "content" .}}
+</body>
+</html>{{end}}
+`
+
+var runsPageTemplate = template.Must(template.Must(template.New("runs").Funcs(pageFuncs).Parse(pageLayout)).Parse(`
+{{define "content"}}
+<h2>Runs</h2>
+{{if not .}}<p>No runs yet.</p>{{else}}
+<table>
+  <tr><th>Run</th><th>Trigger</th><th>Status</th><th>Started</th><th>Duration</th><th>Jobs</th></tr>
+  {{range .}}
+  <tr>
+    <td><a href="/runs/{{.ID}}">{{.ID}}</a></td>
+    <td>{{.Trigger}}</td>
+    <td class="{{.Status}}">{{.Status}}</td>
+    <td>{{time .StartedAt}}</td>
+    <td>{{duration .Duration}}</td>
+    <td>{{range $status, $count := .JobCounts}}<span class="{{$status}}">{{$status}}: {{$count}}</span> {{end}}</td>
+  </tr>
+  {{end}}
+</table>
+{{end}}
+{{end}}
+`))
+
+var runPageTemplate = template.Must(template.Must(template.New("run").Funcs(pageFuncs).Parse(pageLayout)).Parse(`
+{{define "content"}}
+<h2>Run {{.ID}}</h2>
+<p>
+  Trigger: {{.Trigger}}<br>
+  Status: <span class="{{.Status}}">{{.Status}}</span><br>
+  Started: {{time .StartedAt}}<br>
+  Duration: {{duration .Duration}}
+</p>
+{{if .Error}}<pre>{{.Error}}</pre>{{end}}
+{{if not .Jobs}}<p>No jobs.</p>{{else}}
+<table>
+  <tr><th>Repo</th><th>Chore</th><th>Status</th><th>Attempts</th><th>Duration</th><th>PR</th></tr>
+  {{range .Jobs}}
+  <tr>
+    <td>{{.Repo}}</td>
+    <td>{{.ChoreName}}</td>
+    <td class="{{.Status}}">{{.Status}}</td>
+    <td>{{.Attempts}}</td>
+    <td>{{duration .Duration}}</td>
+    <td>{{if .PullRequestURL}}<a href="{{.PullRequestURL}}">{{.PullRequestURL}}</a>{{end}}</td>
+  </tr>
+  {{if .Error}}
+  <tr>
+    <td colspan="6">
+      {{.Error}}{{if .FailedStep}} (step: {{.FailedStep}}){{end}}
+      {{if .Logs}}<pre>{{.Logs}}</pre>{{end}}
+    </td>
+  </tr>
+  {{end}}
+  {{end}}
+</table>
+{{end}}
+{{end}}
+`))
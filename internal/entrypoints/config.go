@@ -1,7 +1,7 @@
 package entrypoints
 
 import (
-	"bytes"
+	"context"
 	"fmt"
 	"log/slog"
 
@@ -9,11 +9,15 @@ import (
 
 	"github.com/markormesher/tedium/internal/platforms"
 	"github.com/markormesher/tedium/internal/schema"
+	"github.com/markormesher/tedium/internal/tracing"
 	"github.com/markormesher/tedium/internal/utils"
-	"gopkg.in/yaml.v3"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-func resolveRepoConfig(_ schema.TediumConfig, targetRepo schema.Repo) (schema.ResolvedRepoConfig, error) {
+func resolveRepoConfig(ctx context.Context, _ schema.TediumConfig, targetRepo schema.Repo) (_ schema.ResolvedRepoConfig, err error) {
+	ctx, span := tracing.Start(ctx, "resolveRepoConfig")
+	defer func() { tracing.End(span, err) }()
+
 	// approach:
 	// - starting from the target repo, recursively follow "extends" urls
 	// - build a LIFO stack of configs to apply, ending with the target repo
@@ -53,7 +57,7 @@ func resolveRepoConfig(_ schema.TediumConfig, targetRepo schema.Repo) (schema.Re
 		}
 
 		var repoConfigRaw []byte
-		repoConfigRaw, err = platform.ReadRepoFile(configRepo, "", utils.AddConfigFileExtensions(fileName))
+		repoConfigRaw, err = readRepoFile(ctx, platform, configRepo, "", utils.AddConfigFileExtensions(fileName))
 		if err != nil {
 			return schema.ResolvedRepoConfig{}, fmt.Errorf("failed to read config file out of repo: %w", err)
 		}
@@ -61,12 +65,9 @@ func resolveRepoConfig(_ schema.TediumConfig, targetRepo schema.Repo) (schema.Re
 			return schema.ResolvedRepoConfig{}, fmt.Errorf("failed to read config file out of repo: no file exists")
 		}
 
-		var repoConfig schema.RepoConfig
-		decoder := yaml.NewDecoder(bytes.NewReader(repoConfigRaw))
-		decoder.KnownFields(true)
-		err = decoder.Decode(&repoConfig)
+		repoConfig, err := schema.ParseRepoConfig(repoConfigRaw)
 		if err != nil {
-			return schema.ResolvedRepoConfig{}, fmt.Errorf("failed to unmarshal repo config file: %w", err)
+			return schema.ResolvedRepoConfig{}, err
 		}
 
 		for _, extendsURL := range repoConfig.Extends {
@@ -100,6 +101,13 @@ func resolveRepoConfig(_ schema.TediumConfig, targetRepo schema.Repo) (schema.Re
 	resolvedConfig := schema.ResolvedRepoConfig{
 		Chores: make([]schema.ChoreSpec, len(mergedConfig.Chores)),
 	}
+
+	for url := range urlsVisited {
+		if url != targetRepo.CloneURL {
+			resolvedConfig.SourceURLs = append(resolvedConfig.SourceURLs, url)
+		}
+	}
+
 	for souceChoreIdx, sourceChore := range mergedConfig.Chores {
 		choreRepoURL := sourceChore.URL
 		choreBranch := sourceChore.Branch
@@ -116,7 +124,7 @@ func resolveRepoConfig(_ schema.TediumConfig, targetRepo schema.Repo) (schema.Re
 		}
 
 		var choreSpecRaw []byte
-		choreSpecRaw, err = platform.ReadRepoFile(choreRepo, choreBranch, utils.AddConfigFileExtensions(fmt.Sprintf("%s/chore", choreDirectory)))
+		choreSpecRaw, err = readRepoFile(ctx, platform, choreRepo, choreBranch, utils.AddConfigFileExtensions(fmt.Sprintf("%s/chore", choreDirectory)))
 		if err != nil {
 			return schema.ResolvedRepoConfig{}, fmt.Errorf("failed to read chore file out of repo: %w", err)
 		}
@@ -124,22 +132,31 @@ func resolveRepoConfig(_ schema.TediumConfig, targetRepo schema.Repo) (schema.Re
 			return schema.ResolvedRepoConfig{}, fmt.Errorf("failed to read chore file out of repo: no file exists")
 		}
 
-		var choreSpec schema.ChoreSpec
-		decoder := yaml.NewDecoder(bytes.NewReader(choreSpecRaw))
-		decoder.KnownFields(true)
-		err = decoder.Decode(&choreSpec)
+		choreSpec, err := schema.ParseChoreSpec(choreSpecRaw)
 		if err != nil {
-			return schema.ResolvedRepoConfig{}, fmt.Errorf("failed to unmarshal chore config file: %w", err)
+			return schema.ResolvedRepoConfig{}, err
 		}
 
 		choreSpec.SourceConfig = sourceChore
 
 		resolvedConfig.Chores[souceChoreIdx] = choreSpec
+		resolvedConfig.SourceURLs = append(resolvedConfig.SourceURLs, choreRepoURL)
 	}
 
 	return resolvedConfig, nil
 }
 
+func readRepoFile(ctx context.Context, platform platforms.Platform, repo schema.Repo, branch string, pathCandidates []string) ([]byte, error) {
+	_, span := tracing.Start(ctx, "platform.ReadRepoFile",
+		attribute.String("tedium.repo", repo.FullName()),
+		attribute.StringSlice("tedium.paths", pathCandidates),
+	)
+	content, err := platform.ReadRepoFile(repo, branch, pathCandidates)
+	tracing.End(span, err)
+
+	return content, err
+}
+
 func mergeRepoConfigs(a, b schema.RepoConfig) (schema.RepoConfig, error) {
 	// merging rules:
 	// - don't copy "extends" URLs, because this happens after they have been explored
@@ -187,6 +204,10 @@ func mergeChoreConfigs(a, b schema.RepoChoreConfig) (schema.RepoChoreConfig, err
 		merged.Branch = b.Branch
 	}
 
+	if b.Schedule != "" {
+		merged.Schedule = b.Schedule
+	}
+
 	if b.Environment != nil {
 		if merged.Environment == nil {
 			merged.Environment = b.Environment
@@ -0,0 +1,52 @@
+package entrypoints
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/markormesher/tedium/internal/platforms"
+	"github.com/markormesher/tedium/internal/schema"
+	"github.com/markormesher/tedium/internal/tracing"
+)
+
+// reportConfigError opens or updates an issue in the repo explaining why its config couldn't be resolved. Failures are logged rather than returned, because the repo has already failed.
+func reportConfigError(ctx context.Context, conf schema.TediumConfig, platform platforms.Platform, repo schema.Repo, configErr error) {
+	if !conf.ConfigErrorIssue.Enabled {
+		return
+	}
+
+	body := fmt.Sprintf("[Tedium](https://github.com/markormesher/tedium) couldn't resolve the config for this repo, so no chores will run until it is fixed. The config is read from `.tedium.yml` (or `.tedium.yaml`/`.tedium.json`) in this repo, plus the `index` file of every repo it extends.\n\n"+
+		"The error was:\n\n%s\n\n"+
+		"This issue is updated after every run, and will be closed automatically once the config can be resolved again.\n", codeBlock(configErr.Error()))
+
+	_, span := tracing.Start(ctx, "platform.OpenOrUpdateIssue")
+	existing, err := platform.FindIssue(repo, conf.ConfigErrorIssue.Title)
+	var issue schema.Issue
+	if err == nil {
+		issue, err = platform.OpenOrUpdateIssue(repo, existing, conf.ConfigErrorIssue.Title, body)
+	}
+	tracing.End(span, err)
+	if err != nil {
+		slog.Warn("error reporting config error to repo", "repo", repo.FullName(), "error", err)
+		return
+	}
+
+	slog.Info("reported config error to repo", "repo", repo.FullName(), "url", issue.URL)
+}
+
+// clearConfigErrors closes the issues opened by reportConfigError in repos whose config has since been resolved or removed. Failures are logged rather than returned, because they don't affect the outcome of the run.
+func clearConfigErrors(conf schema.TediumConfig, report runReport) {
+	if !conf.ConfigErrorIssue.Enabled {
+		return
+	}
+
+	var fixed []repoReport
+	for _, repo := range report.Repos {
+		if repo.Status == repoStatusResolved || (repo.Status == repoStatusSkipped && repo.Reason == reasonNoTediumConfig) {
+			fixed = append(fixed, repo)
+		}
+	}
+
+	closeIssues(conf.ConfigErrorIssue.Title, fixed)
+}
@@ -0,0 +1,217 @@
+package entrypoints
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"log/slog"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/markormesher/tedium/internal/platforms"
+	"github.com/markormesher/tedium/internal/schema"
+)
+
+// dashboardStatePattern finds the state that Tedium stores in a hidden comment at the end of each dashboard.
+var dashboardStatePattern = regexp.MustCompile(`<!-- tedium-dashboard-state: (.*) -->`)
+
+// dashboardState is stored inside the dashboard so that chores which didn't run this time keep showing their last outcome.
+type dashboardState struct {
+	Chores map[string]dashboardChoreState `json:"chores"`
+}
+
+type dashboardChoreState struct {
+	RunID             string    `json:"runID"`
+	FinishedAt        time.Time `json:"finishedAt"`
+	Status            string    `json:"status"`
+	PullRequestNumber int       `json:"pullRequestNumber,omitempty"`
+	PullRequestURL    string    `json:"pullRequestURL,omitempty"`
+	Error             string    `json:"error,omitempty"`
+	FailedStep        string    `json:"failedStep,omitempty"`
+}
+
+// updateDashboards opens, updates or closes the dashboard issue for every repo in the run. Failures are logged rather than returned, because they don't affect the outcome of the run.
+func updateDashboards(conf schema.TediumConfig, report runReport) {
+	if !conf.Dashboard.Enabled {
+		return
+	}
+
+	wg := sync.WaitGroup{}
+	slots := make(chan struct{}, conf.Discovery.Concurrency)
+
+	var unconfigured []repoReport
+	for _, repo := range report.Repos {
+		switch {
+		case repo.Status == repoStatusResolved:
+		case repo.Status == repoStatusFailed && repo.Reason == reasonConfigResolutionFailed:
+		case repo.Status == repoStatusSkipped && repo.Reason == reasonNoTediumConfig:
+			// the repo no longer has a Tedium config, so any dashboard is out of date
+			unconfigured = append(unconfigured, repo)
+			continue
+		default:
+			// either we can't tell whether the repo should have a dashboard, or it can't be modified (e.g. it's archived)
+			continue
+		}
+
+		wg.Go(func() {
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			err := updateDashboard(conf, report.RunID, repo)
+			if err != nil {
+				slog.Warn("error updating dashboard", "repo", repo.Repo, "error", err)
+			}
+		})
+	}
+
+	wg.Wait()
+
+	closeIssues(conf.Dashboard.Title, unconfigured)
+}
+
+func updateDashboard(conf schema.TediumConfig, runID string, repoReport repoReport) error {
+	platform := platforms.FromURL(repoReport.Platform)
+	if platform == nil {
+		return fmt.Errorf("unable to retrieve platform by base URL: %s", repoReport.Platform)
+	}
+
+	ownerName, name, _ := strings.Cut(repoReport.Repo, "/")
+	repo := schema.Repo{OwnerName: ownerName, Name: name}
+	title := conf.Dashboard.Title
+
+	existing, err := platform.FindIssue(repo, title)
+	if err != nil {
+		return err
+	}
+
+	state := dashboardState{Chores: map[string]dashboardChoreState{}}
+	if existing != nil {
+		if match := dashboardStatePattern.FindStringSubmatch(existing.Body); match != nil {
+			err := json.Unmarshal([]byte(match[1]), &state)
+			if err != nil {
+				slog.Warn("ignoring unreadable dashboard state", "repo", repoReport.Repo, "error", err)
+			}
+		}
+	}
+
+	chores := repoReport.Chores
+	if repoReport.Status == repoStatusFailed {
+		// the config couldn't be resolved, so the chores from the last successful resolution are the best we have
+		chores = slices.Sorted(maps.Keys(state.Chores))
+	}
+
+	newState := dashboardState{Chores: map[string]dashboardChoreState{}}
+	for _, chore := range chores {
+		if previous, ok := state.Chores[chore]; ok {
+			newState.Chores[chore] = previous
+		}
+	}
+
+	for _, job := range repoReport.Jobs {
+		switch job.Status {
+		case schema.JobStatusSucceeded, schema.JobStatusFailed, schema.JobStatusTimedOut:
+			newState.Chores[job.ChoreName] = dashboardChoreState{
+				RunID:             job.RunID,
+				FinishedAt:        job.FinishedAt,
+				Status:            job.Status,
+				PullRequestNumber: job.PullRequestNumber,
+				PullRequestURL:    job.PullRequestURL,
+				Error:             job.Error,
+				FailedStep:        job.FailedStep,
+			}
+		}
+	}
+
+	body, err := renderDashboard(runID, repoReport, chores, newState)
+	if err != nil {
+		return err
+	}
+
+	_, err = platform.OpenOrUpdateIssue(repo, existing, title, body)
+	return err
+}
+
+func renderDashboard(runID string, repoReport repoReport, chores []string, state dashboardState) (string, error) {
+	var output strings.Builder
+
+	output.WriteString("This issue is maintained by [Tedium](https://github.com/markormesher/tedium). It lists the chores that apply to this repo and the outcome of their most recent run, and is updated after every run.\n\n")
+
+	if repoReport.Status == repoStatusFailed {
+		output.WriteString("## ⚠️ Config Error\n\n")
+		output.WriteString("Tedium couldn't resolve the config for this repo, so no chores will run until it is fixed. The chores below are from the last time the config was resolved.\n\n")
+		output.WriteString(codeBlock(repoReport.Error) + "\n\n")
+	}
+
+	output.WriteString("## Chores\n\n")
+
+	if len(chores) == 0 {
+		output.WriteString("No chores apply to this repo.\n\n")
+	} else {
+		output.WriteString("| Chore | Status | Last Run | PR |\n")
+		output.WriteString("|---|---|---|---|\n")
+
+		var failures []string
+		for _, chore := range chores {
+			choreState, ran := state.Chores[chore]
+
+			status := "⏸️ not run yet"
+			lastRun := "-"
+			pr := "-"
+
+			if ran {
+				lastRun = choreState.FinishedAt.UTC().Format("2006-01-02 15:04 UTC")
+
+				switch choreState.Status {
+				case schema.JobStatusSucceeded:
+					status = "✅ no changes"
+					if choreState.PullRequestURL != "" {
+						status = "✅ PR opened or updated"
+						pr = fmt.Sprintf("[#%d](%s)", choreState.PullRequestNumber, choreState.PullRequestURL)
+					}
+
+				case schema.JobStatusTimedOut:
+					status = "⏱️ timed out"
+					failures = append(failures, chore)
+
+				default:
+					status = "❌ failed"
+					if choreState.FailedStep != "" {
+						status += " at " + choreState.FailedStep
+					}
+					failures = append(failures, chore)
+				}
+
+				if choreState.RunID != runID {
+					status += " (not run this time)"
+				}
+			}
+
+			fmt.Fprintf(&output, "| %s | %s | %s | %s |\n", markdownTableCell(chore), status, lastRun, pr)
+		}
+
+		output.WriteString("\n")
+
+		for _, chore := range failures {
+			fmt.Fprintf(&output, "<details><summary>Error from <code>%s</code></summary>\n\n%s\n\n</details>\n\n", html.EscapeString(chore), codeBlock(state.Chores[chore].Error))
+		}
+	}
+
+	stateJSON, err := json.Marshal(state)
+	if err != nil {
+		return "", fmt.Errorf("error encoding dashboard state: %w", err)
+	}
+
+	// json.Marshal escapes angle brackets, so the state can't end the comment early
+	fmt.Fprintf(&output, "<!-- tedium-dashboard-state: %s -->\n", stateJSON)
+
+	return output.String(), nil
+}
+
+func markdownTableCell(value string) string {
+	value = strings.ReplaceAll(value, "|", "\\|")
+	return strings.ReplaceAll(value, "\n", " ")
+}
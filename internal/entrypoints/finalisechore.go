@@ -1,66 +1,119 @@
 package entrypoints
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"log/slog"
 	"os"
 
 	"github.com/markormesher/tedium/internal/git"
 	"github.com/markormesher/tedium/internal/platforms"
 	"github.com/markormesher/tedium/internal/schema"
+	"github.com/markormesher/tedium/internal/tracing"
 )
 
 func FinaliseChore() {
-	job, err := schema.JobFromEnvironment()
-	if err != nil {
-		slog.Error("error getting job from environment", "error", err)
-		os.Exit(1)
-	}
+	runInternalCommand("finaliseChore", finaliseChore)
+}
 
+func finaliseChore(ctx context.Context, job schema.Job) error {
 	platform, err := platforms.FromConfig(job.Config, job.PlatformConfig)
 	if err != nil {
-		slog.Error("error getting platform from environment", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("error getting platform from environment: %w", err)
 	}
 
 	err = platform.Init(job.Config)
 	if err != nil {
-		slog.Error("error initialising platform", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("error initialising platform: %w", err)
 	}
 
+	_, span := tracing.Start(ctx, "git.CommitIfChanged")
 	changedThisRun, err := git.CommitIfChanged(job, platform.Profile())
+	tracing.End(span, err)
 	if err != nil {
-		slog.Error("error committing changes", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("error committing changes: %w", err)
 	}
 
 	if !changedThisRun {
 		slog.Info("chore did not modify the repo")
-		os.Exit(0)
-		return
+		return nil
 	}
 
 	changedSincePreviousRuns, err := git.WorkBranchDiffersFromFinalBranch(job)
 	if err != nil {
-		slog.Error("error comparing work and final branches", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("error comparing work and final branches: %w", err)
 	}
 
 	if !changedSincePreviousRuns {
 		slog.Info("identical changes have already been pushed, no need to overwrite them")
-		os.Exit(0)
-		return
+		setSucceededStatus(ctx, platform, job)
+
+		// the existing PR is still reported, so that the run records which PR holds the changes
+		_, span = tracing.Start(ctx, "platform.FindPullRequest")
+		pr, err := platform.FindPullRequest(job)
+		tracing.End(span, err)
+		if err != nil {
+			slog.Warn("error finding existing PR", "error", err)
+			return nil
+		}
+
+		if pr != nil {
+			writeFinaliseResult(schema.FinaliseResult{
+				PullRequestNumber: pr.Number,
+				PullRequestURL:    pr.URL,
+			})
+		}
+
+		return nil
 	}
 
+	_, span = tracing.Start(ctx, "git.PushWorkBranchToFinalBranch")
 	err = git.PushWorkBranchToFinalBranch(job)
+	tracing.End(span, err)
+	if err != nil {
+		return fmt.Errorf("error pushing changes: %w", err)
+	}
+
+	_, span = tracing.Start(ctx, "platform.OpenOrUpdatePullRequest")
+	pr, err := platform.OpenOrUpdatePullRequest(job)
+	tracing.End(span, err)
 	if err != nil {
-		slog.Error("error pushing changes", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("error opening or updating PR: %w", err)
+	}
+
+	slog.Info("opened or updated PR", "url", pr.URL)
+	setSucceededStatus(ctx, platform, job)
+
+	writeFinaliseResult(schema.FinaliseResult{
+		PullRequestNumber:  pr.Number,
+		PullRequestURL:     pr.URL,
+		PullRequestCreated: pr.Created,
+	})
+
+	return nil
+}
+
+// setSucceededStatus marks the PR branch as up to date with the chore. Failing to do so isn't fatal, because the chore itself has succeeded.
+func setSucceededStatus(ctx context.Context, platform platforms.Platform, job schema.Job) {
+	_, span := tracing.Start(ctx, "platform.SetCommitStatus")
+	err := platforms.SetJobStatus(platform, job, schema.CommitStatusSuccess, "Chore succeeded")
+	tracing.End(span, err)
+	if err != nil {
+		slog.Warn("error setting commit status", "error", err)
+	}
+}
+
+// writeFinaliseResult passes the result back to the executor. Failing to do so isn't fatal, because the chore itself has succeeded.
+func writeFinaliseResult(result schema.FinaliseResult) {
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		slog.Warn("error encoding finalise result", "error", err)
+		return
 	}
 
-	err = platform.OpenOrUpdatePullRequest(job)
+	err = os.WriteFile(schema.TerminationMessagePath, resultJSON, 0o644)
 	if err != nil {
-		slog.Error("error opening or updating PR", "error", err)
-		os.Exit(1)
+		slog.Warn("error writing finalise result", "error", err)
 	}
 }
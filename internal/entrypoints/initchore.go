@@ -1,29 +1,32 @@
 package entrypoints
 
 import (
-	"log/slog"
-	"os"
+	"context"
+	"fmt"
 
 	"github.com/markormesher/tedium/internal/git"
 	"github.com/markormesher/tedium/internal/schema"
+	"github.com/markormesher/tedium/internal/tracing"
 )
 
 func InitChore() {
-	job, err := schema.JobFromEnvironment()
-	if err != nil {
-		slog.Error("error getting job from environment", "error", err)
-		os.Exit(1)
-	}
+	runInternalCommand("initChore", initChore)
+}
 
-	err = git.CloneRepo(job, job.Config)
+func initChore(ctx context.Context, job schema.Job) error {
+	_, span := tracing.Start(ctx, "git.CloneRepo")
+	err := git.CloneRepo(job, job.Config)
+	tracing.End(span, err)
 	if err != nil {
-		slog.Error("error cloning repo", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("error cloning repo: %w", err)
 	}
 
+	_, span = tracing.Start(ctx, "git.CheckoutWorkBranch")
 	err = git.CheckoutWorkBranch(job)
+	tracing.End(span, err)
 	if err != nil {
-		slog.Error("error checking out branch for chore", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("error checking out branch for chore: %w", err)
 	}
+
+	return nil
 }
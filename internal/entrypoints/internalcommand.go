@@ -0,0 +1,39 @@
+package entrypoints
+
+import (
+	"context"
+	"log/slog"
+	"os"
+
+	"github.com/markormesher/tedium/internal/schema"
+	"github.com/markormesher/tedium/internal/tracing"
+)
+
+// runInternalCommand runs one of the commands that Tedium performs inside an executor job. The command is traced as part of the job that started it, and Tedium exits with a non-zero status if it fails.
+func runInternalCommand(name string, command func(ctx context.Context, job schema.Job) error) {
+	job, err := schema.JobFromEnvironment()
+	if err != nil {
+		slog.Error("error getting job from environment", "error", err)
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+
+	shutdownTracing, err := tracing.Init(ctx, job.Config)
+	if err != nil {
+		// tracing is not important enough to fail the job over
+		slog.Warn("error initialising tracing", "error", err)
+		shutdownTracing = func() {}
+	}
+
+	ctx = tracing.ContextWithTraceParent(ctx, os.Getenv("TRACEPARENT"))
+	ctx, span := tracing.Start(ctx, name)
+	err = command(ctx, job)
+	tracing.End(span, err)
+	shutdownTracing()
+
+	if err != nil {
+		slog.Error(name+" failed", "error", err)
+		os.Exit(1)
+	}
+}
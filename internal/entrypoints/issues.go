@@ -0,0 +1,61 @@
+package entrypoints
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/markormesher/tedium/internal/platforms"
+	"github.com/markormesher/tedium/internal/schema"
+)
+
+// closeIssues closes the issue with the given title in each of the repos, if it has one. It uses one search per platform rather than looking in every repo, because most repos won't have the issue. Failures are logged rather than returned.
+func closeIssues(title string, repos []repoReport) {
+	byPlatform := map[string][]repoReport{}
+	for _, repo := range repos {
+		byPlatform[repo.Platform] = append(byPlatform[repo.Platform], repo)
+	}
+
+	for baseURL, platformRepos := range byPlatform {
+		platform := platforms.FromURL(baseURL)
+		if platform == nil {
+			slog.Warn("unable to retrieve platform by base URL", "baseURL", baseURL)
+			continue
+		}
+
+		openIssues, err := platform.FindIssuesByTitle(title)
+		if err != nil {
+			slog.Warn("error searching for issues to close", "baseURL", baseURL, "title", title, "error", err)
+			continue
+		}
+
+		for _, repoReport := range platformRepos {
+			issue, ok := openIssues[repoReport.Repo]
+			if !ok {
+				continue
+			}
+
+			ownerName, name, _ := strings.Cut(repoReport.Repo, "/")
+			err := platform.CloseIssue(schema.Repo{OwnerName: ownerName, Name: name}, issue.Number)
+			if err != nil {
+				slog.Warn("error closing issue", "repo", repoReport.Repo, "title", title, "error", err)
+			}
+		}
+	}
+}
+
+// codeBlock wraps text in a Markdown code block, using a fence that is longer than any run of backticks in the text so that the text can't end the block early.
+func codeBlock(text string) string {
+	longestRun := 0
+	run := 0
+	for _, c := range text {
+		if c == '`' {
+			run++
+			longestRun = max(longestRun, run)
+		} else {
+			run = 0
+		}
+	}
+
+	fence := strings.Repeat("`", max(3, longestRun+1))
+	return fence + "\n" + text + "\n" + fence
+}
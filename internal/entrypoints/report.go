@@ -0,0 +1,366 @@
+package entrypoints
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/markormesher/tedium/internal/notifications"
+	"github.com/markormesher/tedium/internal/schema"
+)
+
+// repo statuses used in run reports
+var (
+	repoStatusDiscovered = "discovered"
+	repoStatusSkipped    = "skipped"
+	repoStatusFailed     = "failed"
+	repoStatusResolved   = "resolved"
+)
+
+// jobStatusPending is used in run reports for jobs that were discovered but never started.
+var jobStatusPending = "pending"
+
+type runReport struct {
+	RunID           string       `json:"runID"`
+	Version         string       `json:"version"`
+	StartedAt       time.Time    `json:"startedAt"`
+	FinishedAt      time.Time    `json:"finishedAt"`
+	DurationSeconds float64      `json:"durationSeconds"`
+	Error           string       `json:"error,omitempty"`
+	Repos           []repoReport `json:"repos"`
+}
+
+type repoReport struct {
+	Platform string `json:"platform"`
+	Repo     string `json:"repo"`
+	Status   string `json:"status"`
+
+	// Reason explains why the repo was skipped or failed.
+	Reason string `json:"reason,omitempty"`
+	Error  string `json:"error,omitempty"`
+
+	// Chores lists every chore resolved for the repo, including any that were not selected for this run.
+	Chores []string    `json:"chores,omitempty"`
+	Jobs   []jobReport `json:"jobs,omitempty"`
+}
+
+type jobReport struct {
+	schema.JobResult
+	DurationSeconds float64 `json:"durationSeconds"`
+}
+
+// reportBuilder collects a run's events into a report.
+type reportBuilder struct {
+	report runReport
+}
+
+func newReportBuilder(conf schema.TediumConfig, startedAt time.Time) *reportBuilder {
+	return &reportBuilder{
+		report: runReport{
+			RunID:     conf.RunID,
+			Version:   conf.Version,
+			StartedAt: startedAt,
+			Repos:     []repoReport{},
+		},
+	}
+}
+
+func (b *reportBuilder) handle(e schema.Event) {
+	if e.Repo == "" {
+		return
+	}
+
+	repo := b.repo(e.Platform, e.Repo)
+
+	switch e.Type {
+	case schema.RepoSkipped:
+		repo.Status = repoStatusSkipped
+		repo.Reason = e.Reason
+
+	case schema.RepoFailed:
+		repo.Status = repoStatusFailed
+		repo.Reason = e.Reason
+		repo.Error = e.Error
+
+	case schema.RepoResolved:
+		repo.Status = repoStatusResolved
+		repo.Chores = e.Chores
+
+	case schema.JobDiscovered:
+		job := b.job(repo, e.JobID)
+		job.ChoreName = e.ChoreName
+		job.Status = jobStatusPending
+
+	case schema.JobStarted, schema.JobRetried, schema.JobSucceeded, schema.JobFailed, schema.JobTimedOut:
+		job := b.job(repo, e.JobID)
+		if e.Job != nil {
+			job.JobResult = *e.Job
+			job.DurationSeconds = e.Job.Duration().Seconds()
+		} else {
+			// jobs that fail before reaching the executor don't have a result
+			job.ChoreName = e.ChoreName
+			job.Status = schema.JobStatusFailed
+			job.Error = e.Error
+		}
+	}
+}
+
+func (b *reportBuilder) repo(platform string, name string) *repoReport {
+	idx := slices.IndexFunc(b.report.Repos, func(r repoReport) bool {
+		return r.Platform == platform && r.Repo == name
+	})
+
+	if idx < 0 {
+		b.report.Repos = append(b.report.Repos, repoReport{
+			Platform: platform,
+			Repo:     name,
+			Status:   repoStatusDiscovered,
+		})
+		idx = len(b.report.Repos) - 1
+	}
+
+	return &b.report.Repos[idx]
+}
+
+func (b *reportBuilder) job(repo *repoReport, jobID string) *jobReport {
+	idx := -1
+	if jobID != "" {
+		idx = slices.IndexFunc(repo.Jobs, func(j jobReport) bool {
+			return j.JobID == jobID
+		})
+	}
+
+	if idx < 0 {
+		repo.Jobs = append(repo.Jobs, jobReport{
+			JobResult: schema.JobResult{
+				JobID:    jobID,
+				RunID:    b.report.RunID,
+				Platform: repo.Platform,
+				Repo:     repo.Repo,
+			},
+		})
+		idx = len(repo.Jobs) - 1
+	}
+
+	return &repo.Jobs[idx]
+}
+
+// finish records the end of the run. It must be called before the report is written or summarised. Only the first call has any effect.
+func (b *reportBuilder) finish(runErr error) {
+	if !b.report.FinishedAt.IsZero() {
+		return
+	}
+
+	b.report.FinishedAt = time.Now()
+	b.report.DurationSeconds = b.report.FinishedAt.Sub(b.report.StartedAt).Seconds()
+	if runErr != nil {
+		b.report.Error = runErr.Error()
+	}
+}
+
+// write writes the report to every configured path.
+func (b *reportBuilder) write(conf schema.ReportsConfig) error {
+	var errs []error
+
+	if conf.JSONPath != "" {
+		output, err := json.MarshalIndent(b.report, "", "  ")
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error encoding JSON report: %w", err))
+		} else {
+			errs = append(errs, writeReportFile(conf.JSONPath, output))
+		}
+	}
+
+	if conf.JUnitPath != "" {
+		output, err := xml.MarshalIndent(b.junitReport(), "", "  ")
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error encoding JUnit report: %w", err))
+		} else {
+			errs = append(errs, writeReportFile(conf.JUnitPath, append([]byte(xml.Header), output...)))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func writeReportFile(path string, content []byte) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return fmt.Errorf("error creating report directory: %w", err)
+	}
+
+	err = os.WriteFile(path, content, 0644)
+	if err != nil {
+		return fmt.Errorf("error writing report: %w", err)
+	}
+
+	return nil
+}
+
+// summary condenses the report into what notifications need.
+func (b *reportBuilder) summary() notifications.RunSummary {
+	output := notifications.RunSummary{
+		RunID:      b.report.RunID,
+		StartedAt:  b.report.StartedAt,
+		FinishedAt: b.report.FinishedAt,
+		Duration:   b.report.FinishedAt.Sub(b.report.StartedAt).Round(time.Second),
+		Error:      b.report.Error,
+	}
+
+	for _, repo := range b.report.Repos {
+		output.ReposDiscovered++
+
+		switch repo.Status {
+		case repoStatusSkipped:
+			output.ReposSkipped++
+
+		case repoStatusFailed:
+			output.FailedRepos = append(output.FailedRepos, notifications.RepoFailure{
+				Platform: repo.Platform,
+				Repo:     repo.Repo,
+				Reason:   repo.Reason,
+				Error:    repo.Error,
+			})
+		}
+
+		for _, job := range repo.Jobs {
+			switch job.Status {
+			case schema.JobStatusSucceeded:
+				output.JobsSucceeded++
+				switch {
+				case job.PullRequestURL == "":
+					// the chore didn't change anything
+				case job.PullRequestCreated:
+					output.NewPullRequests = append(output.NewPullRequests, job.JobResult)
+				default:
+					output.UpdatedPullRequests = append(output.UpdatedPullRequests, job.JobResult)
+				}
+
+			case schema.JobStatusFailed, schema.JobStatusTimedOut:
+				output.FailedJobs = append(output.FailedJobs, job.JobResult)
+			}
+		}
+	}
+
+	return output
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     float64          `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+	Type    string `xml:"type,attr,omitempty"`
+	Body    string `xml:",chardata"`
+}
+
+// junitReport converts the report into JUnit's format: each repo is a test suite and each job is a test case. Repos that were skipped or failed before any jobs were created are reported as a single test case.
+func (b *reportBuilder) junitReport() junitTestSuites {
+	output := junitTestSuites{
+		Name: "tedium " + b.report.RunID,
+		Time: b.report.DurationSeconds,
+	}
+
+	for _, repo := range b.report.Repos {
+		suite := junitTestSuite{Name: repo.Repo}
+
+		switch repo.Status {
+		case repoStatusSkipped:
+			suite.Cases = append(suite.Cases, junitTestCase{
+				Name:      "repo",
+				ClassName: repo.Repo,
+				Skipped:   &junitMessage{Message: repo.Reason},
+			})
+
+		case repoStatusFailed:
+			suite.Cases = append(suite.Cases, junitTestCase{
+				Name:      "repo",
+				ClassName: repo.Repo,
+				Error:     &junitMessage{Message: repo.Reason, Body: repo.Error},
+			})
+		}
+
+		for _, job := range repo.Jobs {
+			testCase := junitTestCase{
+				Name:      job.ChoreName,
+				ClassName: repo.Repo,
+				Time:      job.DurationSeconds,
+				SystemOut: job.PullRequestURL,
+			}
+
+			switch job.Status {
+			case schema.JobStatusFailed, schema.JobStatusTimedOut:
+				message := job.Error
+				if job.FailedStep != "" {
+					message = fmt.Sprintf("%s (step: %s)", message, job.FailedStep)
+				}
+				testCase.Failure = &junitMessage{Message: message, Type: job.FailureClass, Body: job.Logs}
+
+			case schema.JobStatusSucceeded:
+				// nothing to add
+
+			default:
+				testCase.Skipped = &junitMessage{Message: "job did not finish"}
+			}
+
+			suite.Cases = append(suite.Cases, testCase)
+
+			if suite.Timestamp == "" && !job.StartedAt.IsZero() {
+				suite.Timestamp = job.StartedAt.Format(time.RFC3339)
+			}
+		}
+
+		for _, testCase := range suite.Cases {
+			suite.Tests++
+			switch {
+			case testCase.Failure != nil:
+				suite.Failures++
+			case testCase.Error != nil:
+				suite.Errors++
+			case testCase.Skipped != nil:
+				suite.Skipped++
+			}
+		}
+
+		output.Tests += suite.Tests
+		output.Failures += suite.Failures
+		output.Errors += suite.Errors
+		output.Skipped += suite.Skipped
+		output.Suites = append(output.Suites, suite)
+	}
+
+	return output
+}
@@ -0,0 +1,215 @@
+package entrypoints
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/markormesher/tedium/internal/platforms"
+	"github.com/markormesher/tedium/internal/schema"
+)
+
+// TestJUnitReportForRun builds a report from the events of real repo processing against a Gitea server, plus the results the executor would send for each job, so that events from the different producers must line up in the report.
+func TestJUnitReportForRun(t *testing.T) {
+	files := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := files[strings.TrimPrefix(r.URL.Path, "/api/v1/repos/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"content": base64.StdEncoding.EncodeToString([]byte(content))})
+	}))
+	defer server.Close()
+
+	files["owner/configured/contents/.tedium.yml"] = "chores:\n" +
+		"  - url: " + server.URL + "/owner/chores\n    directory: lint\n" +
+		"  - url: " + server.URL + "/owner/chores\n    directory: test\n" +
+		"  - url: " + server.URL + "/owner/chores\n    directory: docs\n"
+	files["owner/broken/contents/.tedium.yml"] = "chores: ["
+	for _, name := range []string{"lint", "test", "docs"} {
+		files["owner/chores/contents/"+name+"/chore.yml"] = "name: " + name + "\nsteps:\n  - image: alpine\n    command: make " + name + "\n"
+	}
+
+	platform, err := platforms.FromConfig(schema.TediumConfig{}, schema.PlatformConfig{Type: "gitea", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("error building platform: %v", err)
+	}
+
+	conf := schema.TediumConfig{RunID: "run-1"}
+	jobQueue := make(chan schema.Job, 10)
+	eventQueue := make(chan schema.Event, 100)
+
+	for _, name := range []string{"broken", "configured", "unconfigured"} {
+		repo := schema.Repo{OwnerName: "owner", Name: name, CloneURL: server.URL + "/owner/" + name + ".git"}
+		processRepo(context.Background(), conf, RunOptions{}, discoveredRepo{repo: repo, platform: platform}, jobQueue, eventQueue)
+	}
+	close(jobQueue)
+
+	// the executor finishes the first job, fails the second and never gets to the third
+	startedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	var jobs []schema.Job
+	for job := range jobQueue {
+		jobs = append(jobs, job)
+	}
+	if len(jobs) != 3 {
+		t.Fatalf("got %d jobs, want 3", len(jobs))
+	}
+
+	succeeded := schema.NewJobResult(jobs[0], conf.RunID)
+	succeeded.StartedAt = startedAt
+	succeeded.FinishedAt = startedAt.Add(10 * time.Second)
+	succeeded.Status = schema.JobStatusSucceeded
+	succeeded.PullRequestURL = server.URL + "/owner/configured/pulls/1"
+	eventQueue <- schema.NewJobEvent(schema.JobSucceeded, succeeded)
+
+	failed := schema.NewJobResult(jobs[1], conf.RunID)
+	failed.StartedAt = startedAt
+	failed.FinishedAt = startedAt.Add(5 * time.Second)
+	failed.Status = schema.JobStatusFailed
+	failed.Error = "exit code 2"
+	failed.FailureClass = schema.FailureClassChore
+	failed.FailedStep = "step-1"
+	failed.Logs = "FAIL"
+	eventQueue <- schema.NewJobEvent(schema.JobFailed, failed)
+	close(eventQueue)
+
+	b := newReportBuilder(conf, startedAt)
+	for e := range eventQueue {
+		b.handle(e)
+	}
+	b.finish(nil)
+
+	// the written file is checked rather than the struct, so that the XML mapping is covered too
+	path := filepath.Join(t.TempDir(), "junit.xml")
+	err = b.write(schema.ReportsConfig{JUnitPath: path})
+	if err != nil {
+		t.Fatalf("write() error = %v", err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("error reading report: %v", err)
+	}
+
+	var got junitTestSuites
+	err = xml.Unmarshal(raw, &got)
+	if err != nil {
+		t.Fatalf("error parsing report: %v", err)
+	}
+
+	if got.Name != "tedium run-1" || got.Tests != 5 || got.Failures != 1 || got.Errors != 1 || got.Skipped != 2 {
+		t.Errorf("report %q has %d/%d/%d/%d tests/failures/errors/skipped, want \"tedium run-1\" with 5/1/1/2", got.Name, got.Tests, got.Failures, got.Errors, got.Skipped)
+	}
+
+	if len(got.Suites) != 3 {
+		t.Fatalf("got %d suites, want one per repo: %+v", len(got.Suites), got.Suites)
+	}
+
+	broken := got.Suites[0]
+	if broken.Name != "owner/broken" || len(broken.Cases) != 1 || broken.Cases[0].Error == nil || broken.Cases[0].Error.Message != reasonConfigResolutionFailed {
+		t.Errorf("suite for the repo with invalid config = %+v, want a single errored case", broken)
+	}
+
+	configured := got.Suites[1]
+	if configured.Name != "owner/configured" || configured.Timestamp != startedAt.Format(time.RFC3339) || len(configured.Cases) != 3 {
+		t.Fatalf("suite for the configured repo = %+v, want 3 cases starting at %s", configured, startedAt.Format(time.RFC3339))
+	}
+
+	lint, test, docs := configured.Cases[0], configured.Cases[1], configured.Cases[2]
+	if lint.Name != "lint" || lint.ClassName != "owner/configured" || lint.Time != 10 || lint.SystemOut != succeeded.PullRequestURL {
+		t.Errorf("succeeded case = %+v", lint)
+	}
+	checkJUnitMessage(t, "failure", lint.Failure, nil)
+
+	if test.Name != "test" || test.Time != 5 {
+		t.Errorf("failed case = %+v", test)
+	}
+	checkJUnitMessage(t, "failure", test.Failure, &junitMessage{Message: "exit code 2 (step: step-1)", Type: "chore", Body: "FAIL"})
+
+	if docs.Name != "docs" {
+		t.Errorf("unfinished case = %+v", docs)
+	}
+	checkJUnitMessage(t, "skipped", docs.Skipped, &junitMessage{Message: "job did not finish"})
+
+	unconfigured := got.Suites[2]
+	if unconfigured.Name != "owner/unconfigured" || len(unconfigured.Cases) != 1 {
+		t.Fatalf("suite for the repo without config = %+v, want a single case", unconfigured)
+	}
+	checkJUnitMessage(t, "skipped", unconfigured.Cases[0].Skipped, &junitMessage{Message: reasonNoTediumConfig})
+}
+
+func checkJUnitMessage(t *testing.T, kind string, got *junitMessage, want *junitMessage) {
+	t.Helper()
+
+	if (got == nil) != (want == nil) {
+		t.Errorf("%s = %+v, want %+v", kind, got, want)
+		return
+	}
+
+	if got != nil && *got != *want {
+		t.Errorf("%s = %+v, want %+v", kind, *got, *want)
+	}
+}
+
+// TestReportForUnpreparedJob follows a job that can't be prepared through queueJob, so that the report sees exactly the events that a real run would send.
+func TestReportForUnpreparedJob(t *testing.T) {
+	platform, err := platforms.FromConfig(schema.TediumConfig{}, schema.PlatformConfig{Type: "gitea", BaseURL: "https://gitea.report-test.example.com"})
+	if err != nil {
+		t.Fatalf("error building platform: %v", err)
+	}
+
+	conf := schema.TediumConfig{RunID: "run-1"}
+	conf.Executor.Kubernetes.Pod.ServiceAccountName = "tedium"
+
+	// overriding the service account without operator approval makes preparation fail
+	chore := schema.ChoreSpec{Name: "lint"}
+	chore.Kubernetes.ServiceAccountName = "cluster-admin"
+
+	repo := schema.Repo{OwnerName: "owner", Name: "repo"}
+	jobQueue := make(chan schema.Job, 1)
+	eventQueue := make(chan schema.Event, 10)
+
+	if !queueJob(context.Background(), conf, chore, repo, platform, jobQueue, eventQueue) {
+		t.Fatal("queueJob() reported cancellation")
+	}
+	close(eventQueue)
+
+	if len(jobQueue) != 0 {
+		t.Error("a job that failed preparation was passed to the executor")
+	}
+
+	b := newReportBuilder(conf, time.Now())
+	for e := range eventQueue {
+		b.handle(e)
+	}
+	b.finish(nil)
+
+	if len(b.report.Repos) != 1 {
+		t.Fatalf("got %d repos in the report, want 1", len(b.report.Repos))
+	}
+
+	jobs := b.report.Repos[0].Jobs
+	if len(jobs) != 1 {
+		t.Fatalf("got %d jobs in the report, want 1: %+v", len(jobs), jobs)
+	}
+
+	if jobs[0].Status != schema.JobStatusFailed || jobs[0].ChoreName != "lint" || !strings.Contains(jobs[0].Error, "service account cluster-admin is not allowed") {
+		t.Errorf("unexpected job in the report: %+v", jobs[0])
+	}
+
+	suites := b.junitReport()
+	if suites.Tests != 1 || suites.Failures != 1 {
+		t.Errorf("JUnit report has %d tests and %d failures, want 1 and 1", suites.Tests, suites.Failures)
+	}
+}
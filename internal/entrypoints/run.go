@@ -1,222 +1,452 @@
 package entrypoints
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
-	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/markormesher/tedium/internal/executor"
+	"github.com/markormesher/tedium/internal/metrics"
+	"github.com/markormesher/tedium/internal/notifications"
 	"github.com/markormesher/tedium/internal/platforms"
 	"github.com/markormesher/tedium/internal/schema"
+	"github.com/markormesher/tedium/internal/tracing"
 	"github.com/markormesher/tedium/internal/utils"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-func Run(conf schema.TediumConfig) {
+// reasons given when repos are skipped or fail, where other parts of Tedium need to recognise them
+var (
+	reasonNoTediumConfig         = "repo has no Tedium config"
+	reasonConfigResolutionFailed = "error resolving repo config"
+)
+
+// RunOptions narrows down what a run does.
+type RunOptions struct {
+	// RunID identifies the run. If blank, one is generated.
+	RunID string
+
+	// ChoreFilter, if set, is called for every chore that would be executed; the chore is skipped if it returns false. It may be called concurrently.
+	ChoreFilter func(repo schema.Repo, chore schema.ChoreSpec) bool
+
+	// RepoFilter, if set, is called for every discovered repo; the repo is ignored entirely if it returns false. It may be called concurrently.
+	RepoFilter func(repo schema.Repo) bool
+
+	// OnRepoResolved, if set, is called with the config of every repo that is resolved successfully. It may be called concurrently.
+	OnRepoResolved func(repo schema.Repo, repoConfig schema.ResolvedRepoConfig)
+
+	// Subscribers are called with every event from the run, in order. They are called from a single goroutine, so must not block.
+	Subscribers []func(e schema.Event)
+}
+
+func Run(ctx context.Context, conf schema.TediumConfig, opts RunOptions) error {
+	conf.RunID = opts.RunID
+	if conf.RunID == "" {
+		conf.RunID = utils.UniqueName("run")
+	}
+
+	ctx, span := tracing.Start(ctx, "run", attribute.String("tedium.run.id", conf.RunID))
+	err := run(ctx, conf, opts)
+	tracing.End(span, err)
+	return err
+}
+
+func run(ctx context.Context, conf schema.TediumConfig, opts RunOptions) (err error) {
+	slog.Info("starting run", "runID", conf.RunID)
+	startedAt := time.Now()
+
+	// reports are written however the run ends (including cancellation and setup errors), so that CI always has one to read
+	report := newReportBuilder(conf, startedAt)
+	defer func() {
+		report.finish(err)
+		err = errors.Join(err, report.write(conf.Reports))
+	}()
+
+	// everything started for this run (e.g. the executor's watches) is stopped when it ends
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// init ALL platforms before trying to use ANY of them
+	err = initPlatforms(conf)
+	if err != nil {
+		return err
+	}
+
 	// set up queues
 	jobQueue := make(chan schema.Job, conf.Executor.ChoreConcurrency*100)
 	eventQueue := make(chan schema.Event, conf.Executor.ChoreConcurrency*10)
 
 	// setup the executor
 	slog.Info("initialising executor")
-	err := executor.CreateAndStart(conf, jobQueue, eventQueue)
+	exec, err := executor.CreateAndStart(ctx, conf, jobQueue, eventQueue)
 	if err != nil {
-		slog.Error("could not initialise executor", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("could not initialise executor: %w", err)
 	}
 
 	// gather jobs and feed them to the executor
 	slog.Info("starting to gather chores")
-	go gatherJobs(conf, jobQueue, eventQueue)
-
-	// watch events and wait for completion
-	done := watchEvents(eventQueue)
-	<-done
-}
+	gatherResult := make(chan error, 1)
+	go func() {
+		gatherResult <- gatherJobs(ctx, conf, opts, jobQueue, eventQueue)
+	}()
 
-func watchEvents(eventQueue <-chan schema.Event) chan struct{} {
-	type stats struct {
-		ReposDiscovered int
-		ReposSkipped    int
-		ReposFailed     int
-		JobsDiscovered  int
-		JobsSuceeded    int
-		JobsFailed      int
+	// watch events and wait for completion or cancellation
+	stats := &runStats{}
+	subscribers := append([]func(schema.Event){stats.handle, report.handle, metrics.HandleEvent}, opts.Subscribers...)
+	done, stopped := watchEvents(conf.RunID, eventQueue, subscribers, stats)
+	select {
+	case <-done:
+		stats.logSummary()
+		runErr := errors.Join(<-gatherResult, stats.err(conf.FailureThresholds))
+		metrics.RecordRun(time.Since(startedAt), runErr)
+
+		report.finish(runErr)
+		updateDashboards(conf, report.report)
+		clearConfigErrors(conf, report.report)
+		notifications.Send(ctx, conf.Notifiers, report.summary())
+
+		return runErr
+
+	case <-ctx.Done():
+		slog.Warn("shutdown requested - no more chores will be started, waiting for running chores to stop")
+
+		// events are still consumed while everything stops, so nothing blocks sending them; once every producer has exited the queue can be closed
+		exec.Wait()
+		<-gatherResult
+		close(eventQueue)
+		<-stopped
+
+		return fmt.Errorf("run was stopped before every chore finished: %w", ctx.Err())
 	}
+}
 
-	done := make(chan struct{})
-	discoveryFinished := false
-	s := stats{}
-
-	logProgress := func() {
-		slog.Info("progress", "stats", s)
-	}
+// watchEvents passes every event to the subscribers. It closes done once the run has finished, and stopped once it is no longer reading events (i.e. the run has finished or the queue has been closed).
+func watchEvents(runID string, eventQueue <-chan schema.Event, subscribers []func(schema.Event), stats *runStats) (done chan struct{}, stopped chan struct{}) {
+	done = make(chan struct{})
+	stopped = make(chan struct{})
 
-	// this is the only routine that modifies the state above, so no locking is needed
+	// this is the only routine that calls subscribers, so they don't need any locking
 	go func() {
-		for e := range eventQueue {
-			switch e {
-			case schema.RepoDiscovered:
-				s.ReposDiscovered++
+		defer close(stopped)
 
-			case schema.RepoSkipped:
-				s.ReposSkipped++
+		ticker := time.NewTicker(time.Second * 10)
+		defer ticker.Stop()
 
-			case schema.RepoFailed:
-				s.ReposFailed++
-
-			case schema.DiscoveryFinished:
-				discoveryFinished = true
+		for {
+			var e schema.Event
+			select {
+			case <-ticker.C:
+				// regularly print stats
+				stats.logProgress()
+				continue
 
-			case schema.JobDiscovered:
-				s.JobsDiscovered++
+			case event, ok := <-eventQueue:
+				if !ok {
+					return
+				}
+				e = event
+			}
 
-			case schema.JobSucceeded:
-				s.JobsSuceeded++
+			if e.Time.IsZero() {
+				e.Time = time.Now()
+			}
 
-			case schema.JobFailed:
-				s.JobsFailed++
+			if e.RunID == "" {
+				e.RunID = runID
 			}
 
-			if discoveryFinished && s.JobsDiscovered == s.JobsSuceeded+s.JobsFailed {
-				logProgress()
-				done <- struct{}{}
+			for _, subscriber := range subscribers {
+				subscriber(e)
 			}
-		}
-	}()
 
-	// regularly print stats
-	go func() {
-		for range time.Tick(time.Second * 10) {
-			logProgress()
+			if stats.finished() {
+				close(done)
+				return
+			}
 		}
 	}()
 
-	return done
+	return done, stopped
 }
 
-func gatherJobs(conf schema.TediumConfig, jobQueue chan<- schema.Job, eventQueue chan<- schema.Event) {
-	// init ALL platforms before trying to use ANY of them
+func initPlatforms(conf schema.TediumConfig) error {
+	// platforms are rebuilt for every run in case their config has changed
+	platforms.ClearCache()
+
 	for _, platformConfig := range conf.Platforms {
 		slog.Info("initialising platform", "baseURL", platformConfig.BaseURL)
 		platform, err := platforms.FromConfig(conf, platformConfig)
 		if err != nil {
-			slog.Error("error initialising platform", "error", err)
-			os.Exit(1)
+			return fmt.Errorf("error initialising platform: %w", err)
 		}
 
 		err = platform.Init(conf)
 		if err != nil {
-			slog.Error("error initialising platform", "error", err)
-			os.Exit(1)
+			return fmt.Errorf("error initialising platform: %w", err)
 		}
 	}
 
+	return nil
+}
+
+// gatherJobs discovers repos and feeds their jobs to the executor. Errors from individual repos are reported as events; the returned error covers problems with whole platforms.
+func gatherJobs(ctx context.Context, conf schema.TediumConfig, opts RunOptions, jobQueue chan<- schema.Job, eventQueue chan<- schema.Event) error {
+	// discovery is a pipeline: each platform lists its repos into a shared queue, and a pool of workers checks and resolves config for each one
+	repoQueue := make(chan discoveredRepo, conf.Discovery.Concurrency*10)
+
+	var platformErrs []error
+	platformErrsLock := sync.Mutex{}
+	recordPlatformErr := func(err error) {
+		platformErrsLock.Lock()
+		defer platformErrsLock.Unlock()
+		platformErrs = append(platformErrs, err)
+	}
+
+	discoveryWg := sync.WaitGroup{}
 	for _, platformConfig := range conf.Platforms {
 		platform := platforms.FromURL(platformConfig.BaseURL)
 		if platform == nil {
 			// this shouldn't ever happen
-			slog.Error("unable to retrieve existing platform by base URL", "baseURL", platformConfig.BaseURL)
-			os.Exit(1)
+			recordPlatformErr(fmt.Errorf("unable to retrieve existing platform by base URL: %s", platformConfig.BaseURL))
+			continue
 		}
 
 		if platformConfig.SkipDiscovery {
 			continue
 		}
 
-		slog.Info("discovering repos")
-		allRepos, err := platform.DiscoverRepos()
-		if err != nil {
-			slog.Error("error discovering repos", "error", err)
-			os.Exit(1)
+		discoveryWg.Go(func() {
+			err := discoverRepos(ctx, platform, repoQueue)
+			if err != nil {
+				recordPlatformErr(err)
+			}
+		})
+	}
+
+	go func() {
+		discoveryWg.Wait()
+		close(repoQueue)
+	}()
+
+	resolverWg := sync.WaitGroup{}
+	for range conf.Discovery.Concurrency {
+		resolverWg.Go(func() {
+			for r := range repoQueue {
+				processRepo(ctx, conf, opts, r, jobQueue, eventQueue)
+			}
+		})
+	}
+
+	// every job must have been announced before discovery is marked as finished, otherwise the event watcher may finish early
+	resolverWg.Wait()
+
+	// de-init platforms after ALL of them are finished with
+	for _, platformConfig := range conf.Platforms {
+		platform := platforms.FromURL(platformConfig.BaseURL)
+		if platform == nil {
+			continue
 		}
 
-		slog.Info("finished discovering repos", "count", len(allRepos))
+		slog.Info("de-initialising platform", "baseURL", platformConfig.BaseURL)
+		err := platform.Deinit()
+		if err != nil {
+			recordPlatformErr(fmt.Errorf("error de-initialising platform: %w", err))
+		}
+	}
 
-		for _, targetRepo := range allRepos {
-			eventQueue <- schema.RepoDiscovered
+	eventQueue <- schema.Event{Type: schema.DiscoveryFinished}
+	close(jobQueue)
 
-			if targetRepo.Archived {
-				slog.Info("repo is archived - skipping", "repo", targetRepo.FullName())
-				eventQueue <- schema.RepoSkipped
-				continue
-			}
+	return errors.Join(platformErrs...)
+}
 
-			if targetRepo.Mirror {
-				slog.Info("repo is a mirror - skipping", "repo", targetRepo.FullName())
-				eventQueue <- schema.RepoSkipped
-				continue
-			}
+type discoveredRepo struct {
+	repo     schema.Repo
+	platform platforms.Platform
+}
 
-			if !platformConfig.AcceptsRepo(targetRepo.FullName()) {
-				slog.Info("repo does not match any filter - skipping", "repo", targetRepo.FullName())
-				eventQueue <- schema.RepoSkipped
-				continue
-			}
+func discoverRepos(ctx context.Context, platform platforms.Platform, repoQueue chan<- discoveredRepo) error {
+	slog.Info("discovering repos", "baseURL", platform.Config().BaseURL)
+	_, span := tracing.Start(ctx, "platform.DiscoverRepos", attribute.String("tedium.platform", platform.Config().BaseURL))
+	allRepos, err := platform.DiscoverRepos()
+	tracing.End(span, err)
+	if err != nil {
+		slog.Error("error discovering repos", "baseURL", platform.Config().BaseURL, "error", err)
+		return fmt.Errorf("error discovering repos on %s: %w", platform.Config().BaseURL, err)
+	}
 
-			hasConfig, err := platform.RepoHasTediumConfig(targetRepo)
-			if err != nil {
-				slog.Error("error checking whether repo has a Tedium config", "repo", targetRepo.FullName(), "error", err)
-				eventQueue <- schema.RepoFailed
-				continue
-			}
+	slog.Info("finished discovering repos", "baseURL", platform.Config().BaseURL, "count", len(allRepos))
 
-			if !hasConfig {
-				slog.Info("repo has no Tedium config - skipping", "repo", targetRepo.FullName())
-				eventQueue <- schema.RepoSkipped
-				continue
+	for _, repo := range allRepos {
+		select {
+		case <-ctx.Done():
+			return nil
 
-				// TODO: auto-enrollment
-			}
+		case repoQueue <- discoveredRepo{repo: repo, platform: platform}:
+		}
+	}
 
-			repoConfig, err := resolveRepoConfig(conf, targetRepo)
-			if err != nil {
-				slog.Error("error resolving repo config", "repo", targetRepo.FullName(), "error", err)
-				eventQueue <- schema.RepoFailed
-				continue
-			}
+	return nil
+}
 
-			slog.Info("resolved chores for repo", "repo", targetRepo.FullName(), "chores", len(repoConfig.Chores))
+func processRepo(ctx context.Context, conf schema.TediumConfig, opts RunOptions, r discoveredRepo, jobQueue chan<- schema.Job, eventQueue chan<- schema.Event) {
+	if ctx.Err() != nil {
+		// shutting down - drain the queue without doing any more work
+		return
+	}
 
-			for _, chore := range repoConfig.Chores {
-				eventQueue <- schema.JobDiscovered
+	targetRepo := r.repo
+	platform := r.platform
+	platformConfig := platform.Config()
 
-				job, err := prepareJob(conf, chore, targetRepo, platform)
-				if err != nil {
-					slog.Error("error preparing job", "repo", targetRepo.FullName(), "chore", chore.Name, "error", err)
-					eventQueue <- schema.JobFailed
-					continue
-				}
+	if opts.RepoFilter != nil && !opts.RepoFilter(targetRepo) {
+		return
+	}
 
-				jobQueue <- job
-			}
+	ctx, span := tracing.Start(ctx, "repo",
+		attribute.String("tedium.platform", platformConfig.BaseURL),
+		attribute.String("tedium.repo", targetRepo.FullName()),
+	)
+	defer span.End()
+
+	repoEvent := func(eventType schema.EventType) schema.Event {
+		return schema.Event{
+			Type:     eventType,
+			Platform: platformConfig.BaseURL,
+			Repo:     targetRepo.FullName(),
 		}
 	}
 
-	// de-init platforms after ALL of them are finished with
-	for _, platformConfig := range conf.Platforms {
-		platform := platforms.FromURL(platformConfig.BaseURL)
-		if platform == nil {
-			// this shouldn't ever happen
-			slog.Error("unable to retrieve existing platform by base URL", "baseURL", platformConfig.BaseURL)
-			os.Exit(1)
+	skipRepo := func(reason string) {
+		slog.Info("skipping repo", "repo", targetRepo.FullName(), "reason", reason)
+		e := repoEvent(schema.RepoSkipped)
+		e.Reason = reason
+		eventQueue <- e
+	}
+
+	failRepo := func(message string, err error) {
+		slog.Error(message, "repo", targetRepo.FullName(), "error", err)
+		e := repoEvent(schema.RepoFailed)
+		e.Reason = message
+		e.Error = err.Error()
+		eventQueue <- e
+		tracing.SetError(span, err)
+	}
+
+	eventQueue <- repoEvent(schema.RepoDiscovered)
+
+	if targetRepo.Archived {
+		skipRepo("repo is archived")
+		return
+	}
+
+	if targetRepo.Mirror {
+		skipRepo("repo is a mirror")
+		return
+	}
+
+	if !platformConfig.AcceptsRepo(targetRepo.FullName()) {
+		skipRepo("repo does not match any filter")
+		return
+	}
+
+	_, checkSpan := tracing.Start(ctx, "platform.RepoHasTediumConfig")
+	hasConfig, err := platform.RepoHasTediumConfig(targetRepo)
+	tracing.End(checkSpan, err)
+	if err != nil {
+		failRepo("error checking whether repo has a Tedium config", err)
+		return
+	}
+
+	if !hasConfig {
+		skipRepo(reasonNoTediumConfig)
+		return
+
+		// TODO: auto-enrollment
+	}
+
+	repoConfig, err := resolveRepoConfig(ctx, conf, targetRepo)
+	if err != nil {
+		failRepo(reasonConfigResolutionFailed, err)
+		reportConfigError(ctx, conf, platform, targetRepo, err)
+		return
+	}
+
+	slog.Info("resolved chores for repo", "repo", targetRepo.FullName(), "chores", len(repoConfig.Chores))
+
+	if opts.OnRepoResolved != nil {
+		opts.OnRepoResolved(targetRepo, repoConfig)
+	}
+
+	resolved := repoEvent(schema.RepoResolved)
+	for _, chore := range repoConfig.Chores {
+		resolved.Chores = append(resolved.Chores, chore.Name)
+	}
+	eventQueue <- resolved
+
+	for _, chore := range repoConfig.Chores {
+		if opts.ChoreFilter != nil && !opts.ChoreFilter(targetRepo, chore) {
+			slog.Info("chore not selected for this run - skipping", "repo", targetRepo.FullName(), "chore", chore.Name)
+			continue
 		}
 
-		slog.Info("de-initialising platform", "baseURL", platformConfig.BaseURL)
-		err := platform.Deinit()
-		if err != nil {
-			slog.Error("error de-initialising platform", "error", err)
-			os.Exit(1)
+		if !queueJob(ctx, conf, chore, targetRepo, platform, jobQueue, eventQueue) {
+			return
+		}
+	}
+}
+
+// queueJob prepares a job for a chore and passes it to the executor. Jobs that can't be prepared are reported as failed without reaching the executor. It returns false if the run was cancelled before the job could be queued.
+func queueJob(ctx context.Context, conf schema.TediumConfig, chore schema.ChoreSpec, targetRepo schema.Repo, platform platforms.Platform, jobQueue chan<- schema.Job, eventQueue chan<- schema.Event) bool {
+	// the job's span is ended by the executor once the job has finished
+	jobCtx, jobSpan := tracing.Start(ctx, "job", attribute.String("tedium.chore", chore.Name))
+
+	// the ID is assigned before preparation, so that a failure can still be attributed to the job
+	jobID := utils.UniqueName("job")
+	jobSpan.SetAttributes(attribute.String("tedium.job.id", jobID))
+
+	jobEvent := func(eventType schema.EventType) schema.Event {
+		return schema.Event{
+			Type:      eventType,
+			Platform:  platform.Config().BaseURL,
+			Repo:      targetRepo.FullName(),
+			JobID:     jobID,
+			ChoreName: chore.Name,
 		}
 	}
 
-	eventQueue <- schema.DiscoveryFinished
-	close(jobQueue)
+	job, err := prepareJob(jobCtx, conf, jobID, chore, targetRepo, platform)
+	job.Span = jobSpan
+
+	eventQueue <- jobEvent(schema.JobDiscovered)
+
+	if err != nil {
+		slog.Error("error preparing job", "repo", targetRepo.FullName(), "chore", chore.Name, "error", err)
+		failed := jobEvent(schema.JobFailed)
+		failed.Reason = "error preparing job"
+		failed.Error = err.Error()
+		eventQueue <- failed
+		tracing.End(jobSpan, err)
+		return true
+	}
+
+	select {
+	case <-ctx.Done():
+		jobSpan.End()
+		return false
+
+	case jobQueue <- job:
+		return true
+	}
 }
 
-func prepareJob(conf schema.TediumConfig, chore schema.ChoreSpec, targetRepo schema.Repo, platform platforms.Platform) (schema.Job, error) {
+func prepareJob(ctx context.Context, conf schema.TediumConfig, jobID string, chore schema.ChoreSpec, targetRepo schema.Repo, platform platforms.Platform) (schema.Job, error) {
 	job := schema.Job{
+		ID:              jobID,
 		Config:          conf,
 		Repo:            targetRepo,
 		Chore:           chore,
@@ -225,52 +455,66 @@ func prepareJob(conf schema.TediumConfig, chore schema.ChoreSpec, targetRepo sch
 		FinalBranchName: utils.ConvertToBranchName(chore.Name),
 	}
 
-	jobEnvBundle, err := job.ToEnvironment()
+	err := conf.Executor.Kubernetes.Pod.CheckOverrides(chore.Kubernetes, conf.Executor.Kubernetes.ChoreOverrides)
 	if err != nil {
-		return schema.Job{}, fmt.Errorf("error generating job environment variable: %w", err)
+		return schema.Job{}, fmt.Errorf("chore overrides pod settings in ways that are not allowed: %w", err)
 	}
 
 	tediumImage := conf.Images.Tedium
 
 	if !job.Chore.SkipCloneStep {
 		tediumStep := schema.ChoreStep{
-			Image:       tediumImage,
-			Command:     "/usr/local/bin/tedium --internal-command initChore",
-			Environment: jobEnvBundle,
-			Internal:    true,
+			Image:    tediumImage,
+			Command:  "/usr/local/bin/tedium --internal-command initChore",
+			Internal: true,
 		}
 		job.Chore.Steps = append([]schema.ChoreStep{tediumStep}, job.Chore.Steps...)
 	}
 
 	if !job.Chore.SkipFinaliseStep {
 		tediumStep := schema.ChoreStep{
-			Image:       tediumImage,
-			Command:     "/usr/local/bin/tedium --internal-command finaliseChore",
-			Environment: jobEnvBundle,
-			Internal:    true,
+			Image:    tediumImage,
+			Command:  "/usr/local/bin/tedium --internal-command finaliseChore",
+			Internal: true,
 		}
 		job.Chore.Steps = append(job.Chore.Steps, tediumStep)
 	}
 
 	job.ExecutionSteps = make([]schema.ExecutionStep, len(job.Chore.Steps))
 	for i, step := range job.Chore.Steps {
+		stage := schema.StepStageChore
+		if step.Internal {
+			if i == 0 && !job.Chore.SkipCloneStep {
+				stage = schema.StepStageClone
+			} else {
+				stage = schema.StepStageFinalise
+			}
+		}
+
 		job.ExecutionSteps[i] = schema.ExecutionStep{
-			Label:       fmt.Sprintf("step-%d", i+1),
-			Image:       step.Image,
-			Command:     step.Command,
-			Environment: envForStep(platform, job, step),
+			Label:          fmt.Sprintf("step-%d", i+1),
+			Stage:          stage,
+			Image:          step.Image,
+			Command:        step.Command,
+			Environment:    envForStep(ctx, platform, job, step),
+			TimeoutSeconds: step.TimeoutSeconds,
 		}
 	}
 
 	return job, nil
 }
 
-func envForStep(platform platforms.Platform, job schema.Job, step schema.ChoreStep) map[string]string {
+func envForStep(ctx context.Context, platform platforms.Platform, job schema.Job, step schema.ChoreStep) map[string]string {
 	env := map[string]string{}
 
 	// used by Tedium directly
 	env["TEDIUM_COMMAND"] = step.Command
 
+	// lets Tedium's own steps (and any chore that supports it) continue the job's trace
+	if traceParent := tracing.TraceParent(ctx); traceParent != "" {
+		env["TRACEPARENT"] = traceParent
+	}
+
 	// not used by Tedium directly
 	env["TEDIUM_REPO_OWNER"] = job.Repo.OwnerName
 	env["TEDIUM_REPO_NAME"] = job.Repo.Name
@@ -280,9 +524,8 @@ func envForStep(platform platforms.Platform, job schema.Job, step schema.ChoreSt
 	env["TEDIUM_PLATFORM_BASE_URL"] = platform.Config().BaseURL
 	env["TEDIUM_PLATFORM_API_BASE_URL"] = platform.APIBaseURL().String()
 	env["TEDIUM_PLATFORM_EMAIL"] = platform.Profile().Email
-	if job.Chore.SourceConfig.ExposePlatformToken {
-		env["TEDIUM_PLATFORM_TOKEN"] = platform.AuthToken()
-	}
+
+	// TEDIUM_JOB and TEDIUM_PLATFORM_TOKEN carry credentials, so they are added by the executor when the job starts
 
 	for k, v := range step.Environment {
 		if !step.Internal && strings.HasPrefix(k, "TEDIUM_") {
@@ -0,0 +1,174 @@
+package entrypoints
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/markormesher/tedium/internal/schema"
+)
+
+// maxStoredRuns limits how many runs the daemon remembers; older runs are forgotten.
+const maxStoredRuns = 100
+
+// run statuses reported by the daemon
+var (
+	runStatusQueued    = "queued"
+	runStatusRunning   = "running"
+	runStatusSucceeded = "succeeded"
+	runStatusFailed    = "failed"
+	runStatusCancelled = "cancelled"
+)
+
+// runTriggers describe why a run was started
+var (
+	runTriggerSchedule = "schedule"
+	runTriggerWebhook  = "webhook"
+	runTriggerAPI      = "api"
+)
+
+type runRecord struct {
+	ID         string    `json:"id"`
+	Trigger    string    `json:"trigger"`
+	Status     string    `json:"status"`
+	QueuedAt   time.Time `json:"queuedAt"`
+	StartedAt  time.Time `json:"startedAt,omitzero"`
+	FinishedAt time.Time `json:"finishedAt,omitzero"`
+	Error      string    `json:"error,omitempty"`
+
+	// JobCounts counts the run's jobs by status.
+	JobCounts map[string]int `json:"jobCounts"`
+
+	Jobs []schema.JobResult `json:"jobs,omitempty"`
+}
+
+// Duration returns how long the run took, or has been running for so far.
+func (r runRecord) Duration() time.Duration {
+	switch {
+	case r.StartedAt.IsZero():
+		return 0
+	case r.FinishedAt.IsZero():
+		return time.Since(r.StartedAt)
+	default:
+		return r.FinishedAt.Sub(r.StartedAt)
+	}
+}
+
+// runStore keeps the history of recent runs in memory.
+type runStore struct {
+	lock sync.Mutex
+	runs []*runRecord
+}
+
+func (s *runStore) add(id string, trigger string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.runs = append(s.runs, &runRecord{
+		ID:        id,
+		Trigger:   trigger,
+		Status:    runStatusQueued,
+		QueuedAt:  time.Now(),
+		JobCounts: map[string]int{},
+	})
+
+	if len(s.runs) > maxStoredRuns {
+		s.runs = s.runs[len(s.runs)-maxStoredRuns:]
+	}
+}
+
+func (s *runStore) start(id string) {
+	s.update(id, func(r *runRecord) {
+		r.Status = runStatusRunning
+		r.StartedAt = time.Now()
+	})
+}
+
+func (s *runStore) finish(id string, err error, cancelled bool) {
+	s.update(id, func(r *runRecord) {
+		r.FinishedAt = time.Now()
+
+		switch {
+		case cancelled:
+			r.Status = runStatusCancelled
+		case err != nil:
+			r.Status = runStatusFailed
+			r.Error = err.Error()
+		default:
+			r.Status = runStatusSucceeded
+		}
+	})
+}
+
+// recordJob adds or updates a job within its run.
+func (s *runStore) recordJob(result schema.JobResult) {
+	s.update(result.RunID, func(r *runRecord) {
+		idx := slices.IndexFunc(r.Jobs, func(j schema.JobResult) bool {
+			return j.JobID == result.JobID
+		})
+
+		if idx < 0 {
+			r.Jobs = append(r.Jobs, result)
+		} else {
+			r.JobCounts[r.Jobs[idx].Status]--
+			r.Jobs[idx] = result
+		}
+
+		r.JobCounts[result.Status]++
+	})
+}
+
+func (s *runStore) update(id string, fn func(r *runRecord)) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, r := range s.runs {
+		if r.ID == id {
+			fn(r)
+			return
+		}
+	}
+}
+
+// list returns all stored runs, newest first, without their jobs.
+func (s *runStore) list() []runRecord {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	output := make([]runRecord, 0, len(s.runs))
+	for _, r := range slices.Backward(s.runs) {
+		summary := *r
+		summary.JobCounts = copyCounts(r.JobCounts)
+		summary.Jobs = nil
+		output = append(output, summary)
+	}
+
+	return output
+}
+
+func (s *runStore) get(id string) (runRecord, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, r := range s.runs {
+		if r.ID == id {
+			output := *r
+			output.JobCounts = copyCounts(r.JobCounts)
+			output.Jobs = slices.Clone(r.Jobs)
+			return output, true
+		}
+	}
+
+	return runRecord{}, false
+}
+
+func copyCounts(counts map[string]int) map[string]int {
+	output := map[string]int{}
+	for k, v := range counts {
+		if v > 0 {
+			output[k] = v
+		}
+	}
+
+	return output
+}
@@ -0,0 +1,347 @@
+package entrypoints
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/markormesher/tedium/internal/metrics"
+	"github.com/markormesher/tedium/internal/schema"
+	"github.com/markormesher/tedium/internal/utils"
+	"github.com/robfig/cron/v3"
+)
+
+// daemon runs Tedium repeatedly on a schedule, re-reading its config before every run.
+type daemon struct {
+	// ctx is cancelled when the daemon is shutting down
+	ctx context.Context
+
+	configFilePath string
+	version        string
+
+	// conf is the most recently loaded valid config
+	confLock sync.RWMutex
+	conf     schema.TediumConfig
+
+	// runLock ensures only one run happens at a time
+	runLock sync.Mutex
+
+	// runs records current and past runs
+	runs runStore
+
+	// lastChoreRuns records when each repo/chore pair last started a run that succeeded, so chores with their own schedule only run when due
+	lastChoreRunsLock sync.Mutex
+	lastChoreRuns     map[string]time.Time
+
+	// dependents maps each repo to the target repos whose config reads from it (including itself), as learnt from resolving config during runs
+	dependentsLock sync.Mutex
+	dependents     map[string]map[string]bool
+
+	// pendingRepos are target repos waiting for a triggered run; triggered is signalled when repos are added
+	pendingReposLock sync.Mutex
+	pendingRepos     map[string]bool
+	triggered        chan struct{}
+}
+
+func Serve(ctx context.Context, conf schema.TediumConfig, configFilePath string, version string) error {
+	if conf.Daemon.Schedule == "" {
+		return fmt.Errorf("a daemon schedule must be configured to use daemon mode")
+	}
+
+	d := &daemon{
+		ctx:            ctx,
+		configFilePath: configFilePath,
+		version:        version,
+		conf:           conf,
+		lastChoreRuns:  map[string]time.Time{},
+		dependents:     map[string]map[string]bool{},
+		pendingRepos:   map[string]bool{},
+		triggered:      make(chan struct{}, 1),
+	}
+
+	if conf.Daemon.ListenAddress != "" {
+		err := d.startServer(ctx, conf.Daemon.ListenAddress)
+		if err != nil {
+			return err
+		}
+	}
+
+	go d.processTriggers(ctx)
+
+	for {
+		schedule, err := cron.ParseStandard(d.currentConfig().Daemon.Schedule)
+		if err != nil {
+			// config is validated when it is loaded, so this shouldn't ever happen
+			return fmt.Errorf("invalid daemon schedule: %w", err)
+		}
+
+		next := schedule.Next(time.Now())
+		slog.Info("waiting for next scheduled run", "at", next)
+
+		select {
+		case <-ctx.Done():
+			slog.Info("shutdown requested - stopping daemon")
+			return nil
+
+		case <-time.After(time.Until(next)):
+		}
+
+		d.reloadConfig()
+
+		_, done := d.queueRun(runTriggerSchedule, RunOptions{
+			ChoreFilter: d.choreIsDue,
+			Subscribers: []func(schema.Event){d.recordChoreRun},
+		})
+
+		err = <-done
+		if err != nil && ctx.Err() == nil {
+			slog.Error("run failed", "error", err)
+		}
+	}
+}
+
+func (d *daemon) startServer(ctx context.Context, listenAddress string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /webhook", d.handleWebhook)
+	mux.Handle("GET /metrics", metrics.Handler())
+	d.registerAPI(mux)
+
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	listener, err := net.Listen("tcp", listenAddress)
+	if err != nil {
+		return fmt.Errorf("error starting HTTP server: %w", err)
+	}
+
+	go func() {
+		slog.Info("starting HTTP server", "address", listenAddress)
+		err := server.Serve(listener)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("HTTP server failed", "error", err)
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		err := server.Shutdown(shutdownCtx)
+		if err != nil {
+			slog.Warn("error shutting down HTTP server", "error", err)
+		}
+	}()
+
+	return nil
+}
+
+func (d *daemon) currentConfig() schema.TediumConfig {
+	d.confLock.RLock()
+	defer d.confLock.RUnlock()
+
+	return d.conf
+}
+
+// reloadConfig re-reads the config file, keeping the previous config if the new one is invalid.
+func (d *daemon) reloadConfig() {
+	conf, err := schema.LoadTediumConfig(d.configFilePath, d.version)
+	if err != nil {
+		slog.Error("error reloading configuration - continuing with the previous configuration", "error", err)
+		return
+	}
+
+	if conf.Daemon.Schedule == "" {
+		slog.Error("reloaded configuration has no daemon schedule - continuing with the previous configuration")
+		return
+	}
+
+	d.confLock.Lock()
+	defer d.confLock.Unlock()
+
+	d.conf = conf
+}
+
+// queueRun starts a run in the background once any run already in progress has finished. It returns the ID of the new run and a channel that receives the run's result.
+func (d *daemon) queueRun(trigger string, opts RunOptions) (string, <-chan error) {
+	opts.RunID = utils.UniqueName("run")
+	opts.OnRepoResolved = d.recordDependencies
+	opts.Subscribers = append(opts.Subscribers, func(e schema.Event) {
+		if e.Job != nil {
+			d.runs.recordJob(*e.Job)
+		}
+	})
+
+	d.runs.add(opts.RunID, trigger)
+
+	done := make(chan error, 1)
+	go func() {
+		d.runLock.Lock()
+		defer d.runLock.Unlock()
+
+		if d.ctx.Err() != nil {
+			d.runs.finish(opts.RunID, nil, true)
+			done <- d.ctx.Err()
+			return
+		}
+
+		d.runs.start(opts.RunID)
+		err := Run(d.ctx, d.currentConfig(), opts)
+		d.runs.finish(opts.RunID, err, d.ctx.Err() != nil)
+		done <- err
+	}()
+
+	return opts.RunID, done
+}
+
+// choreIsDue reports whether a chore's own schedule has fired since it last succeeded. Chores without a schedule are always due, as are chores that haven't succeeded since the daemon started.
+func (d *daemon) choreIsDue(repo schema.Repo, chore schema.ChoreSpec) bool {
+	scheduleStr := chore.EffectiveSchedule()
+	if scheduleStr == "" {
+		return true
+	}
+
+	schedule, err := cron.ParseStandard(scheduleStr)
+	if err != nil {
+		slog.Error("invalid chore schedule - skipping", "repo", repo.FullName(), "chore", chore.Name, "schedule", scheduleStr, "error", err)
+		return false
+	}
+
+	platformURL := ""
+	if platform := d.platformForURL(repo.CloneURL); platform != nil {
+		platformURL = platform.Config().BaseURL
+	}
+
+	d.lastChoreRunsLock.Lock()
+	defer d.lastChoreRunsLock.Unlock()
+
+	lastRun, seen := d.lastChoreRuns[choreRunKey(platformURL, repo.FullName(), chore.SourceConfig.URL+"#"+chore.SourceConfig.Directory)]
+	return !seen || !schedule.Next(lastRun).After(time.Now())
+}
+
+// recordChoreRun records when a chore started a run that went on to succeed. Failed chores aren't recorded, so they are tried again on the next scheduled run instead of waiting for their own schedule to fire again.
+func (d *daemon) recordChoreRun(e schema.Event) {
+	if e.Type != schema.JobSucceeded || e.Job == nil {
+		return
+	}
+
+	d.lastChoreRunsLock.Lock()
+	defer d.lastChoreRunsLock.Unlock()
+
+	d.lastChoreRuns[choreRunKey(e.Platform, e.Repo, e.Job.ChoreURL)] = e.Job.StartedAt
+}
+
+// choreRunKey identifies a repo/chore pair using only details that are available both when chores are selected and in job events.
+func choreRunKey(platformURL string, repo string, choreURL string) string {
+	return utils.SHA256String(platformURL + "#" + repo + "#" + choreURL)
+}
+
+// repoKey normalises a repo URL so that the different forms of it (e.g. clone URL vs. web URL, or alternate base URLs) can be compared.
+func (d *daemon) repoKey(url string) string {
+	if platform := d.platformForURL(url); platform != nil {
+		if canonical, ok := platform.AcceptsURL(url); ok {
+			url = canonical
+		}
+	}
+
+	url = strings.TrimSuffix(url, "/")
+	url = strings.TrimSuffix(url, ".git")
+	return strings.ToLower(url)
+}
+
+// recordDependencies updates the dependency index with the repos that a target repo's config was resolved from.
+func (d *daemon) recordDependencies(repo schema.Repo, repoConfig schema.ResolvedRepoConfig) {
+	target := d.repoKey(repo.CloneURL)
+	sources := []string{target}
+	for _, url := range repoConfig.SourceURLs {
+		sources = append(sources, d.repoKey(url))
+	}
+
+	d.dependentsLock.Lock()
+	defer d.dependentsLock.Unlock()
+
+	// forget old dependencies, in case the repo has stopped using something
+	for _, targets := range d.dependents {
+		delete(targets, target)
+	}
+
+	for _, source := range sources {
+		if d.dependents[source] == nil {
+			d.dependents[source] = map[string]bool{}
+		}
+		d.dependents[source][target] = true
+	}
+}
+
+// dependentsOf returns every target repo affected by a change to the given repo, including the repo itself.
+func (d *daemon) dependentsOf(url string) []string {
+	source := d.repoKey(url)
+
+	d.dependentsLock.Lock()
+	defer d.dependentsLock.Unlock()
+
+	targets := slices.Collect(maps.Keys(d.dependents[source]))
+	if !slices.Contains(targets, source) {
+		// the repo may not have been seen yet (e.g. it was just enrolled)
+		targets = append(targets, source)
+	}
+
+	return targets
+}
+
+// triggerRepos queues a run for the given target repos. Triggers that arrive while a run is in progress are combined into a single run.
+func (d *daemon) triggerRepos(repoKeys []string) {
+	d.pendingReposLock.Lock()
+	for _, key := range repoKeys {
+		d.pendingRepos[key] = true
+	}
+	d.pendingReposLock.Unlock()
+
+	select {
+	case d.triggered <- struct{}{}:
+	default:
+		// a run is already queued and will pick these repos up
+	}
+}
+
+func (d *daemon) processTriggers(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-d.triggered:
+		}
+
+		d.pendingReposLock.Lock()
+		repoKeys := d.pendingRepos
+		d.pendingRepos = map[string]bool{}
+		d.pendingReposLock.Unlock()
+
+		if len(repoKeys) == 0 {
+			continue
+		}
+
+		_, done := d.queueRun(runTriggerWebhook, RunOptions{
+			RepoFilter: func(repo schema.Repo) bool {
+				return repoKeys[d.repoKey(repo.CloneURL)]
+			},
+		})
+
+		err := <-done
+		if err != nil && ctx.Err() == nil {
+			slog.Error("triggered run failed", "error", err)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package entrypoints
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/markormesher/tedium/internal/platforms"
+	"github.com/markormesher/tedium/internal/schema"
+)
+
+// TestChoreIsDueAfterRuns follows a scheduled chore through queueJob and the events the executor sends, so that the key used to record a run must match the one used to select chores.
+func TestChoreIsDueAfterRuns(t *testing.T) {
+	platform, err := platforms.FromConfig(schema.TediumConfig{}, schema.PlatformConfig{Type: "gitea", BaseURL: "https://gitea.serve-test.example.com"})
+	if err != nil {
+		t.Fatalf("error building platform: %v", err)
+	}
+
+	conf := schema.TediumConfig{RunID: "run-1"}
+	repo := schema.Repo{OwnerName: "owner", Name: "repo", CloneURL: "https://gitea.serve-test.example.com/owner/repo.git"}
+	chore := schema.ChoreSpec{
+		Name:         "lint",
+		Schedule:     "@every 1h",
+		SourceConfig: schema.RepoChoreConfig{URL: "https://gitea.serve-test.example.com/owner/chores.git", Directory: "lint"},
+		Steps:        []schema.ChoreStep{{Image: "alpine", Command: "true"}},
+	}
+
+	d := &daemon{lastChoreRuns: map[string]time.Time{}}
+
+	// finishRun queues the chore like a real run would and reports its outcome like the executor would
+	finishRun := func(eventType schema.EventType, startedAt time.Time) {
+		t.Helper()
+
+		jobQueue := make(chan schema.Job, 1)
+		eventQueue := make(chan schema.Event, 10)
+		if !queueJob(context.Background(), conf, chore, repo, platform, jobQueue, eventQueue) || len(jobQueue) != 1 {
+			t.Fatal("queueJob() did not queue the chore")
+		}
+
+		result := schema.NewJobResult(<-jobQueue, conf.RunID)
+		result.StartedAt = startedAt
+		d.recordChoreRun(schema.NewJobEvent(schema.JobStarted, result))
+		d.recordChoreRun(schema.NewJobEvent(eventType, result))
+	}
+
+	if !d.choreIsDue(repo, chore) {
+		t.Fatal("chore was not due before it had ever run")
+	}
+
+	finishRun(schema.JobFailed, time.Now().Add(-10*time.Minute))
+	if !d.choreIsDue(repo, chore) {
+		t.Error("chore was not due after it failed")
+	}
+
+	finishRun(schema.JobSucceeded, time.Now().Add(-10*time.Minute))
+	if d.choreIsDue(repo, chore) {
+		t.Error("chore was due again 10 minutes after it succeeded")
+	}
+
+	otherRepo := repo
+	otherRepo.Name = "other"
+	otherRepo.CloneURL = "https://gitea.serve-test.example.com/owner/other.git"
+	if !d.choreIsDue(otherRepo, chore) {
+		t.Error("a chore succeeding in one repo stopped it being due in another")
+	}
+
+	finishRun(schema.JobSucceeded, time.Now().Add(-90*time.Minute))
+	if !d.choreIsDue(repo, chore) {
+		t.Error("chore was not due 90 minutes after it succeeded")
+	}
+}
+
+func TestChoreIsDueSchedules(t *testing.T) {
+	repo := schema.Repo{OwnerName: "owner", Name: "repo", CloneURL: "https://unknown.example.com/owner/repo.git"}
+	sourceConfig := schema.RepoChoreConfig{URL: "https://unknown.example.com/owner/chores.git", Directory: "lint"}
+	lastRun := time.Now().Add(-90 * time.Minute)
+
+	d := &daemon{lastChoreRuns: map[string]time.Time{
+		// repos on platforms that aren't configured any more are keyed without a platform
+		choreRunKey("", repo.FullName(), sourceConfig.URL+"#"+sourceConfig.Directory): lastRun,
+	}}
+
+	check := func(choreSchedule string, repoSchedule string, want bool) {
+		t.Helper()
+
+		chore := schema.ChoreSpec{Name: "lint", Schedule: choreSchedule, SourceConfig: sourceConfig}
+		chore.SourceConfig.Schedule = repoSchedule
+		if got := d.choreIsDue(repo, chore); got != want {
+			t.Errorf("choreIsDue() with chore schedule %q and repo schedule %q = %v, want %v", choreSchedule, repoSchedule, got, want)
+		}
+	}
+
+	check("", "", true)
+	check("@every 1h", "", true)
+	check("@every 2h", "", false)
+	check("@every 1h", "@every 24h", false)
+	check("@every 24h", "@every 1h", true)
+	check("every so often", "", false)
+
+	// selecting a chore must not count as running it
+	if got := d.lastChoreRuns[choreRunKey("", repo.FullName(), sourceConfig.URL+"#"+sourceConfig.Directory)]; !got.Equal(lastRun) {
+		t.Errorf("choreIsDue() changed the last run from %v to %v", lastRun, got)
+	}
+}
@@ -0,0 +1,118 @@
+package entrypoints
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/markormesher/tedium/internal/schema"
+)
+
+// runStats tracks a run's progress from its events. It backs the progress log, the final summary and the result of the run.
+type runStats struct {
+	reposDiscovered int
+	reposSkipped    int
+	reposFailed     int
+	jobsDiscovered  int
+	jobsSucceeded   int
+	jobsFailed      int
+	jobsTimedOut    int
+	jobsRetried     int
+
+	discoveryFinished bool
+
+	failedRepos  []schema.Event
+	failedJobs   []schema.Event
+	pullRequests []schema.Event
+}
+
+func (s *runStats) handle(e schema.Event) {
+	switch e.Type {
+	case schema.RepoDiscovered:
+		s.reposDiscovered++
+
+	case schema.RepoSkipped:
+		s.reposSkipped++
+
+	case schema.RepoFailed:
+		s.reposFailed++
+		s.failedRepos = append(s.failedRepos, e)
+
+	case schema.DiscoveryFinished:
+		s.discoveryFinished = true
+
+	case schema.JobDiscovered:
+		s.jobsDiscovered++
+
+	case schema.JobSucceeded:
+		s.jobsSucceeded++
+		if e.PullRequestURL != "" {
+			s.pullRequests = append(s.pullRequests, e)
+		}
+
+	case schema.JobFailed:
+		s.jobsFailed++
+		s.failedJobs = append(s.failedJobs, e)
+
+	case schema.JobTimedOut:
+		s.jobsTimedOut++
+		s.failedJobs = append(s.failedJobs, e)
+
+	case schema.JobRetried:
+		s.jobsRetried++
+	}
+}
+
+// finished reports whether every job that will be run has been run.
+func (s *runStats) finished() bool {
+	return s.discoveryFinished && s.jobsDiscovered == s.jobsSucceeded+s.jobsFailed+s.jobsTimedOut
+}
+
+func (s *runStats) logProgress() {
+	slog.Info("progress",
+		"reposDiscovered", s.reposDiscovered,
+		"reposSkipped", s.reposSkipped,
+		"reposFailed", s.reposFailed,
+		"jobsDiscovered", s.jobsDiscovered,
+		"jobsSucceeded", s.jobsSucceeded,
+		"jobsFailed", s.jobsFailed,
+		"jobsTimedOut", s.jobsTimedOut,
+		"jobsRetried", s.jobsRetried,
+	)
+}
+
+func (s *runStats) logSummary() {
+	s.logProgress()
+
+	for _, e := range s.pullRequests {
+		slog.Info("summary: PR opened or updated", "repo", e.Repo, "chore", e.ChoreName, "url", e.PullRequestURL)
+	}
+
+	for _, e := range s.failedRepos {
+		slog.Error("summary: repo failed", "repo", e.Repo, "error", e.Error)
+	}
+
+	for _, e := range s.failedJobs {
+		slog.Error("summary: job failed", "repo", e.Repo, "chore", e.ChoreName, "jobID", e.JobID, "error", e.Error)
+	}
+}
+
+// err returns an error if the failures in the run exceed the configured thresholds.
+func (s *runStats) err(thresholds schema.FailureThresholdsConfig) error {
+	if s.reposFailed == 0 && s.jobsFailed == 0 && s.jobsTimedOut == 0 {
+		return nil
+	}
+
+	failedJobsPercent := 0.0
+	if s.jobsDiscovered > 0 {
+		failedJobsPercent = float64(s.jobsFailed+s.jobsTimedOut) / float64(s.jobsDiscovered) * 100
+	}
+
+	summary := fmt.Sprintf("%d repo(s) failed, %d job(s) failed and %d job(s) timed out", s.reposFailed, s.jobsFailed, s.jobsTimedOut)
+
+	if s.reposFailed > thresholds.MaxFailedRepos || failedJobsPercent > thresholds.MaxFailedJobsPercent {
+		return fmt.Errorf("failure thresholds exceeded: %s", summary)
+	}
+
+	slog.Warn("run had failures within the configured thresholds", "failures", summary, "failedJobsPercent", failedJobsPercent)
+	return nil
+}
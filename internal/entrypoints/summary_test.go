@@ -0,0 +1,159 @@
+package entrypoints
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/markormesher/tedium/internal/platforms"
+	"github.com/markormesher/tedium/internal/schema"
+)
+
+// TestRunStatsForRun discovers repos from a Gitea server and feeds the run's events through watchEvents, so that the run only finishes once every job discovered has reported back, including jobs that failed before reaching the executor.
+func TestRunStatsForRun(t *testing.T) {
+	var server *httptest.Server
+	files := map[string]string{}
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.URL.Path == "/api/v1/repos/search" {
+			_, _ = w.Write([]byte(`{"data": [
+				{"name": "app", "clone_url": "` + server.URL + `/owner/app.git", "owner": {"username": "owner"}},
+				{"name": "broken", "clone_url": "` + server.URL + `/owner/broken.git", "owner": {"username": "owner"}}
+			]}`))
+			return
+		}
+
+		content, ok := files[strings.TrimPrefix(r.URL.Path, "/api/v1/repos/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]string{"content": base64.StdEncoding.EncodeToString([]byte(content))})
+	}))
+	defer server.Close()
+
+	files["owner/app/contents/.tedium.yml"] = "chores:\n" +
+		"  - url: " + server.URL + "/owner/chores\n    directory: lint\n" +
+		"  - url: " + server.URL + "/owner/chores\n    directory: test\n" +
+		"  - url: " + server.URL + "/owner/chores\n    directory: deploy\n"
+	files["owner/broken/contents/.tedium.yml"] = "chores: ["
+	files["owner/chores/contents/lint/chore.yml"] = "name: lint\nsteps:\n  - image: alpine\n    command: make lint\n"
+	files["owner/chores/contents/test/chore.yml"] = "name: test\nsteps:\n  - image: alpine\n    command: make test\n"
+	// the service account isn't allowed by the runtime config, so this job fails while it's being prepared and never reaches the executor
+	files["owner/chores/contents/deploy/chore.yml"] = "name: deploy\nkubernetes:\n  serviceAccountName: cluster-admin\nsteps:\n  - image: alpine\n    command: make deploy\n"
+
+	platformConfig := schema.PlatformConfig{
+		Type:    "gitea",
+		BaseURL: server.URL,
+		Auth:    &schema.AuthConfig{Type: schema.AuthConfigTypeUserToken, TokenString: "token"},
+	}
+	conf := schema.TediumConfig{
+		RunID:     "run-1",
+		Platforms: []schema.PlatformConfig{platformConfig},
+		Discovery: schema.DiscoveryConfig{Concurrency: 2},
+	}
+
+	_, err := platforms.FromConfig(conf, platformConfig)
+	if err != nil {
+		t.Fatalf("error building platform: %v", err)
+	}
+
+	jobQueue := make(chan schema.Job, 10)
+	eventQueue := make(chan schema.Event, 100)
+
+	stats := &runStats{}
+	done, _ := watchEvents(conf.RunID, eventQueue, []func(schema.Event){stats.handle}, stats)
+
+	// the executor succeeds at linting and fails the tests
+	go func() {
+		for job := range jobQueue {
+			result := schema.NewJobResult(job, conf.RunID)
+			eventQueue <- schema.NewJobEvent(schema.JobStarted, result)
+
+			if job.Chore.Name == "lint" {
+				result.Status = schema.JobStatusSucceeded
+				eventQueue <- schema.NewJobEvent(schema.JobSucceeded, result)
+			} else {
+				result.Status = schema.JobStatusFailed
+				result.Error = "exit code 2"
+				eventQueue <- schema.NewJobEvent(schema.JobFailed, result)
+			}
+		}
+	}()
+
+	err = gatherJobs(context.Background(), conf, RunOptions{}, jobQueue, eventQueue)
+	if err != nil {
+		t.Fatalf("gatherJobs() error = %v", err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("run did not finish; stats = %+v", stats)
+	}
+
+	if stats.reposDiscovered != 2 || stats.reposFailed != 1 || stats.jobsDiscovered != 3 || stats.jobsSucceeded != 1 || stats.jobsFailed != 2 {
+		t.Errorf("stats = %+v, want 2 repos with 1 failed and 3 jobs with 1 succeeded and 2 failed", stats)
+	}
+
+	var preparationFailures []string
+	for _, e := range stats.failedJobs {
+		if e.Reason == "error preparing job" {
+			preparationFailures = append(preparationFailures, e.ChoreName)
+		}
+	}
+	if len(preparationFailures) != 1 || preparationFailures[0] != "deploy" {
+		t.Errorf("jobs that failed preparation = %v, want only deploy", preparationFailures)
+	}
+
+	if err := stats.err(schema.FailureThresholdsConfig{}); err == nil {
+		t.Error("err() with default thresholds = nil, want an error")
+	}
+
+	if err := stats.err(schema.FailureThresholdsConfig{MaxFailedRepos: 1, MaxFailedJobsPercent: 70}); err != nil {
+		t.Errorf("err() with failures within the thresholds = %v, want nil", err)
+	}
+
+	if err := stats.err(schema.FailureThresholdsConfig{MaxFailedRepos: 1, MaxFailedJobsPercent: 60}); err == nil {
+		t.Error("err() with 2 of 3 jobs failed and a 60% threshold = nil, want an error")
+	}
+}
+
+func TestRunStatsErr(t *testing.T) {
+	tests := []struct {
+		name       string
+		stats      runStats
+		thresholds schema.FailureThresholdsConfig
+		wantErr    bool
+	}{
+		{name: "no failures", stats: runStats{reposDiscovered: 5, jobsDiscovered: 10, jobsSucceeded: 10}},
+		{name: "nothing discovered", stats: runStats{}},
+		{name: "failed repo with default thresholds", stats: runStats{reposDiscovered: 5, reposFailed: 1}, wantErr: true},
+		{name: "failed job with default thresholds", stats: runStats{jobsDiscovered: 10, jobsFailed: 1}, wantErr: true},
+		{name: "timed out job with default thresholds", stats: runStats{jobsDiscovered: 10, jobsTimedOut: 1}, wantErr: true},
+		{name: "failed repos within threshold", stats: runStats{reposFailed: 2}, thresholds: schema.FailureThresholdsConfig{MaxFailedRepos: 2}},
+		{name: "failed repos over threshold", stats: runStats{reposFailed: 3}, thresholds: schema.FailureThresholdsConfig{MaxFailedRepos: 2}, wantErr: true},
+		{name: "failed jobs at threshold", stats: runStats{jobsDiscovered: 10, jobsFailed: 1}, thresholds: schema.FailureThresholdsConfig{MaxFailedJobsPercent: 10}},
+		{name: "failed jobs over threshold", stats: runStats{jobsDiscovered: 10, jobsFailed: 2}, thresholds: schema.FailureThresholdsConfig{MaxFailedJobsPercent: 10}, wantErr: true},
+		{name: "failed and timed out jobs count together", stats: runStats{jobsDiscovered: 10, jobsFailed: 1, jobsTimedOut: 1}, thresholds: schema.FailureThresholdsConfig{MaxFailedJobsPercent: 10}, wantErr: true},
+		{name: "every job failed with full tolerance", stats: runStats{jobsDiscovered: 4, jobsFailed: 4}, thresholds: schema.FailureThresholdsConfig{MaxFailedJobsPercent: 100}},
+		{name: "job thresholds don't cover repos", stats: runStats{reposFailed: 1, jobsDiscovered: 10}, thresholds: schema.FailureThresholdsConfig{MaxFailedJobsPercent: 100}, wantErr: true},
+		{name: "repo thresholds don't cover jobs", stats: runStats{jobsDiscovered: 10, jobsFailed: 1}, thresholds: schema.FailureThresholdsConfig{MaxFailedRepos: 10}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.stats.err(tt.thresholds)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
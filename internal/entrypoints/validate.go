@@ -0,0 +1,77 @@
+package entrypoints
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/markormesher/tedium/internal/schema"
+	"github.com/markormesher/tedium/internal/utils"
+)
+
+// Validate checks config files without contacting any platform, so that mistakes can be caught before Tedium reads them (e.g. in pre-commit hooks or CI). The runtime config at configFilePath, if set, is loaded exactly as it would be for a run. Every other file is treated as a repo config or a chore definition based on its name.
+func Validate(configFilePath string, paths []string) error {
+	if configFilePath == "" && len(paths) == 0 {
+		slog.Error("no files to validate")
+		return fmt.Errorf("no files to validate")
+	}
+
+	var errs []error
+
+	if configFilePath != "" {
+		_, err := schema.LoadTediumConfig(configFilePath, "")
+		errs = append(errs, validationResult(configFilePath, err))
+	}
+
+	for _, path := range paths {
+		errs = append(errs, validationResult(path, validateFile(path)))
+	}
+
+	return errors.Join(errs...)
+}
+
+func validateFile(path string) error {
+	if !utils.HasConfigFileExtension(path) {
+		return fmt.Errorf("unacceptable file format: %s", path)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading file: %w", err)
+	}
+
+	base := filepath.Base(path)
+	switch strings.TrimSuffix(base, filepath.Ext(base)) {
+	case ".tedium", "index":
+		repoConfig, err := schema.ParseRepoConfig(raw)
+		if err != nil {
+			return err
+		}
+
+		return repoConfig.Validate()
+
+	case "chore":
+		choreSpec, err := schema.ParseChoreSpec(raw)
+		if err != nil {
+			return err
+		}
+
+		return choreSpec.Validate()
+
+	default:
+		return fmt.Errorf("unable to tell what kind of config %s is: repo configs must be named .tedium or index, and chore definitions must be named chore (pass runtime configs with -config)", path)
+	}
+}
+
+func validationResult(path string, err error) error {
+	if err != nil {
+		slog.Error("invalid config", "file", path, "error", err)
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	slog.Info("valid config", "file", path)
+	return nil
+}
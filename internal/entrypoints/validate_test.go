@@ -0,0 +1,71 @@
+package entrypoints
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestValidate runs the validate command over a tree of files, so that each file is checked as the right kind of config and every invalid file is reported at once.
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content string) string {
+		t.Helper()
+
+		path := filepath.Join(dir, name)
+		err := os.MkdirAll(filepath.Dir(path), 0o755)
+		if err != nil {
+			t.Fatalf("error creating directory: %v", err)
+		}
+
+		err = os.WriteFile(path, []byte(content), 0o644)
+		if err != nil {
+			t.Fatalf("error writing %s: %v", name, err)
+		}
+
+		return path
+	}
+
+	runtimeConfig := write("config.yml", "platforms:\n  - type: gitea\n    baseURL: https://gitea.example.com\n")
+	repoConfig := write(".tedium.yml", "chores:\n  - url: https://gitea.example.com/owner/chores\n    directory: lint\n")
+	sharedConfig := write("shared/index.yaml", "chores:\n  - url: https://gitea.example.com/owner/chores\n    directory: fmt\n    schedule: \"@daily\"\n")
+	chore := write("lint/chore.yml", "name: lint\nsteps:\n  - image: alpine\n    command: make lint\n")
+
+	err := Validate(runtimeConfig, []string{repoConfig, sharedConfig, chore})
+	if err != nil {
+		t.Fatalf("Validate() on valid files error = %v", err)
+	}
+
+	// a chore definition in a file named like a repo config is valid YAML, so it is only caught if each file is checked against the schema its name implies
+	choreNamedAsRepoConfig := write("misnamed/.tedium.yml", "name: lint\nsteps:\n  - image: alpine\n    command: make lint\n")
+	incompleteChore := write("fmt/chore.yml", "name: fmt\nsteps:\n  - image: alpine\n")
+	unknownKind := write("notes.yml", "chores: []\n")
+	notConfig := write("README.md", "# chores\n")
+	duplicatePlatforms := write("duplicate.yml", "platforms:\n  - type: gitea\n    baseURL: https://gitea.example.com\n  - type: gitea\n    baseURL: https://gitea.example.com\n")
+
+	err = Validate(duplicatePlatforms, []string{repoConfig, choreNamedAsRepoConfig, incompleteChore, chore, unknownKind, notConfig})
+	if err == nil {
+		t.Fatal("Validate() on invalid files error = nil")
+	}
+
+	for _, path := range []string{duplicatePlatforms, choreNamedAsRepoConfig, incompleteChore, unknownKind, notConfig} {
+		if !strings.Contains(err.Error(), path+":") {
+			t.Errorf("Validate() error %q does not report %s", err, path)
+		}
+	}
+
+	for _, path := range []string{repoConfig, chore} {
+		if strings.Contains(err.Error(), path+":") {
+			t.Errorf("Validate() error %q reports valid file %s", err, path)
+		}
+	}
+
+	if !strings.Contains(err.Error(), "steps[0]: command is required") {
+		t.Errorf("Validate() error %q does not say what is wrong with %s", err, incompleteChore)
+	}
+
+	if err := Validate("", nil); err == nil {
+		t.Error("Validate() with no files error = nil")
+	}
+}
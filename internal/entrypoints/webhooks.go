@@ -0,0 +1,170 @@
+package entrypoints
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/markormesher/tedium/internal/platforms"
+	"github.com/markormesher/tedium/internal/schema"
+)
+
+// maxWebhookBodyBytes matches the largest payload GitHub will send.
+const maxWebhookBodyBytes = 25 * 1024 * 1024
+
+// pushPayload contains the fields we need from a push event. GitHub and Gitea use the same names for them.
+type pushPayload struct {
+	Ref        string `json:"ref"`
+	Repository struct {
+		FullName      string `json:"full_name"`
+		CloneURL      string `json:"clone_url"`
+		DefaultBranch string `json:"default_branch"`
+	} `json:"repository"`
+}
+
+// webhookSource describes how a platform type identifies and signs its webhooks.
+type webhookSource struct {
+	eventHeader     string
+	signatureHeader string
+	signaturePrefix string
+}
+
+var webhookSources = map[string]webhookSource{
+	// Gitea also sends GitHub's headers for compatibility, so it must be checked first
+	"gitea": {
+		eventHeader:     "X-Gitea-Event",
+		signatureHeader: "X-Gitea-Signature",
+		signaturePrefix: "",
+	},
+	"github": {
+		eventHeader:     "X-GitHub-Event",
+		signatureHeader: "X-Hub-Signature-256",
+		signaturePrefix: "sha256=",
+	},
+}
+
+var webhookSourceOrder = []string{"gitea", "github"}
+
+func (d *daemon) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	platformType, source, ok := identifyWebhook(r)
+	if !ok {
+		http.Error(w, "unrecognised webhook source", http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBodyBytes))
+	if err != nil {
+		http.Error(w, "error reading body", http.StatusBadRequest)
+		return
+	}
+
+	event := r.Header.Get(source.eventHeader)
+	if event == "ping" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if event != "push" {
+		slog.Debug("ignoring webhook event", "event", event)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	var payload pushPayload
+	err = json.Unmarshal(body, &payload)
+	if err != nil {
+		http.Error(w, "error parsing body", http.StatusBadRequest)
+		return
+	}
+
+	platform := d.platformForURL(payload.Repository.CloneURL)
+	if platform == nil || platform.Config().Type != platformType {
+		slog.Warn("received webhook for a repo on an unknown platform", "repo", payload.Repository.CloneURL)
+		http.Error(w, "unknown platform", http.StatusNotFound)
+		return
+	}
+
+	err = verifyWebhookSignature(platform.Config(), source, r.Header.Get(source.signatureHeader), body)
+	if err != nil {
+		slog.Warn("rejected webhook", "repo", payload.Repository.FullName, "error", err)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	// only default branch changes affect chores; this also stops Tedium's own pushes to chore branches from triggering more runs
+	if payload.Ref != "refs/heads/"+payload.Repository.DefaultBranch {
+		slog.Debug("ignoring push to non-default branch", "repo", payload.Repository.FullName, "ref", payload.Ref)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	targets := d.dependentsOf(payload.Repository.CloneURL)
+	slog.Info("received push webhook - triggering run", "repo", payload.Repository.FullName, "targetRepos", len(targets))
+	d.triggerRepos(targets)
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func identifyWebhook(r *http.Request) (string, webhookSource, bool) {
+	for _, platformType := range webhookSourceOrder {
+		source := webhookSources[platformType]
+		if r.Header.Get(source.eventHeader) != "" {
+			return platformType, source, true
+		}
+	}
+
+	return "", webhookSource{}, false
+}
+
+func verifyWebhookSignature(platformConfig schema.PlatformConfig, source webhookSource, signatureHeader string, body []byte) error {
+	secret, err := platformConfig.ReadWebhookSecret()
+	if err != nil {
+		return err
+	}
+
+	if secret == "" {
+		return fmt.Errorf("no webhook secret is configured for %s", platformConfig.BaseURL)
+	}
+
+	signatureHex, ok := strings.CutPrefix(signatureHeader, source.signaturePrefix)
+	if !ok {
+		return fmt.Errorf("malformed signature")
+	}
+
+	signature, err := hex.DecodeString(signatureHex)
+	if err != nil {
+		return fmt.Errorf("malformed signature: %w", err)
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return fmt.Errorf("signature mismatch")
+	}
+
+	return nil
+}
+
+// platformForURL finds the configured platform for a URL. Platforms are normally built at the start of each run, so they are built here if a webhook arrives before the first run.
+func (d *daemon) platformForURL(url string) platforms.Platform {
+	platform := platforms.FromURL(url)
+	if platform != nil {
+		return platform
+	}
+
+	conf := d.currentConfig()
+	for _, platformConfig := range conf.Platforms {
+		_, err := platforms.FromConfig(conf, platformConfig)
+		if err != nil {
+			slog.Warn("error building platform", "baseURL", platformConfig.BaseURL, "error", err)
+		}
+	}
+
+	return platforms.FromURL(url)
+}
@@ -0,0 +1,137 @@
+package entrypoints
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/markormesher/tedium/internal/schema"
+)
+
+func sign(secret string, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// TestHandleWebhook sends webhooks through the daemon's handler, so that identifying the source, finding the platform and checking the signature are covered together.
+func TestHandleWebhook(t *testing.T) {
+	const secret = "s3cret"
+	const giteaRepo = "https://gitea.webhook-test.example.com/owner/repo"
+	const githubRepo = "https://github.webhook-test.example.com/owner/repo"
+
+	conf := schema.TediumConfig{
+		Platforms: []schema.PlatformConfig{
+			{Type: "gitea", BaseURL: "https://gitea.webhook-test.example.com", WebhookSecret: secret},
+			{Type: "github", BaseURL: "https://github.webhook-test.example.com", WebhookSecret: secret},
+			{Type: "gitea", BaseURL: "https://unsigned.webhook-test.example.com"},
+		},
+	}
+
+	push := func(cloneURL string, ref string) string {
+		return `{"ref": "` + ref + `", "repository": {"full_name": "owner/repo", "clone_url": "` + cloneURL + `.git", "default_branch": "main"}}`
+	}
+
+	// send delivers a webhook to a fresh daemon and reports the response status and which repos were queued for a run
+	send := func(headers map[string]string, body string) (int, []string) {
+		d := &daemon{
+			conf:         conf,
+			dependents:   map[string]map[string]bool{},
+			pendingRepos: map[string]bool{},
+			triggered:    make(chan struct{}, 1),
+		}
+
+		r := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+		for k, v := range headers {
+			r.Header.Set(k, v)
+		}
+
+		w := httptest.NewRecorder()
+		d.handleWebhook(w, r)
+
+		var queued []string
+		for key := range d.pendingRepos {
+			queued = append(queued, key)
+		}
+
+		return w.Code, queued
+	}
+
+	t.Run("signed pushes to the default branch trigger a run", func(t *testing.T) {
+		body := push(giteaRepo, "refs/heads/main")
+		status, queued := send(map[string]string{"X-Gitea-Event": "push", "X-Gitea-Signature": sign(secret, body)}, body)
+		if status != http.StatusAccepted || len(queued) != 1 || queued[0] != giteaRepo {
+			t.Errorf("Gitea push got %d and queued %v, want 202 and %s", status, queued, giteaRepo)
+		}
+
+		body = push(githubRepo, "refs/heads/main")
+		status, queued = send(map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": "sha256=" + sign(secret, body)}, body)
+		if status != http.StatusAccepted || len(queued) != 1 || queued[0] != githubRepo {
+			t.Errorf("GitHub push got %d and queued %v, want 202 and %s", status, queued, githubRepo)
+		}
+	})
+
+	t.Run("Gitea's GitHub compatibility headers are ignored", func(t *testing.T) {
+		body := push(giteaRepo, "refs/heads/main")
+		status, queued := send(map[string]string{
+			"X-Gitea-Event":       "push",
+			"X-Gitea-Signature":   sign(secret, body),
+			"X-GitHub-Event":      "push",
+			"X-Hub-Signature-256": "sha256=" + sign(secret, body),
+		}, body)
+		if status != http.StatusAccepted || len(queued) != 1 {
+			t.Errorf("got %d and queued %v, want 202 and one repo", status, queued)
+		}
+	})
+
+	t.Run("bad signatures are rejected", func(t *testing.T) {
+		body := push(githubRepo, "refs/heads/main")
+		for name, signature := range map[string]string{
+			"missing":                "",
+			"missing prefix":         sign(secret, body),
+			"wrong secret":           "sha256=" + sign("other", body),
+			"signs a different body": "sha256=" + sign(secret, body+" "),
+			"not hex":                "sha256=zz",
+		} {
+			status, queued := send(map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": signature}, body)
+			if status != http.StatusUnauthorized || len(queued) != 0 {
+				t.Errorf("%s signature got %d and queued %v, want 401 and nothing", name, status, queued)
+			}
+		}
+	})
+
+	t.Run("platforms without a secret reject every webhook", func(t *testing.T) {
+		body := push("https://unsigned.webhook-test.example.com/owner/repo", "refs/heads/main")
+		status, queued := send(map[string]string{"X-Gitea-Event": "push", "X-Gitea-Signature": sign("", body)}, body)
+		if status != http.StatusUnauthorized || len(queued) != 0 {
+			t.Errorf("got %d and queued %v, want 401 and nothing", status, queued)
+		}
+	})
+
+	t.Run("webhooks from the wrong platform type are rejected", func(t *testing.T) {
+		body := push(giteaRepo, "refs/heads/main")
+		status, queued := send(map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": "sha256=" + sign(secret, body)}, body)
+		if status != http.StatusNotFound || len(queued) != 0 {
+			t.Errorf("got %d and queued %v, want 404 and nothing", status, queued)
+		}
+	})
+
+	t.Run("other events don't trigger runs", func(t *testing.T) {
+		body := push(giteaRepo, "refs/heads/tedium/lint")
+		if status, queued := send(map[string]string{"X-Gitea-Event": "push", "X-Gitea-Signature": sign(secret, body)}, body); status != http.StatusAccepted || len(queued) != 0 {
+			t.Errorf("push to another branch got %d and queued %v, want 202 and nothing", status, queued)
+		}
+
+		if status, queued := send(map[string]string{"X-Gitea-Event": "ping"}, "{}"); status != http.StatusOK || len(queued) != 0 {
+			t.Errorf("ping got %d and queued %v, want 200 and nothing", status, queued)
+		}
+
+		if status, _ := send(map[string]string{}, body); status != http.StatusBadRequest {
+			t.Errorf("webhook from an unknown source got %d, want 400", status)
+		}
+	})
+}
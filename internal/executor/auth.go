@@ -0,0 +1,52 @@
+package executor
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/markormesher/tedium/internal/platforms"
+	"github.com/markormesher/tedium/internal/schema"
+)
+
+// withFreshAuth fills in a job's platform credentials as an attempt starts rather than when the job was prepared, so that short-lived tokens (e.g. GitHub App installation tokens) don't expire while the job waits in the queue or between retries.
+func withFreshAuth(job schema.Job) (schema.Job, error) {
+	platform := platforms.FromURL(job.PlatformConfig.BaseURL)
+	if platform == nil {
+		return schema.Job{}, fmt.Errorf("error getting platform credentials: no platform for %s", job.PlatformConfig.BaseURL)
+	}
+
+	auth, err := platform.JobAuth()
+	if err != nil {
+		return schema.Job{}, fmt.Errorf("error getting platform credentials: %w", err)
+	}
+
+	job.PlatformConfig.Auth = auth
+	job.Repo.Auth.Password = auth.Token()
+
+	jobEnv, err := job.ToEnvironment()
+	if err != nil {
+		return schema.Job{}, fmt.Errorf("error generating job environment variable: %w", err)
+	}
+
+	// the steps are copied so that the credentials don't leak back into the job that later attempts start from
+	steps := make([]schema.ExecutionStep, len(job.ExecutionSteps))
+	for i, step := range job.ExecutionSteps {
+		step.Environment = maps.Clone(step.Environment)
+		if step.Environment == nil {
+			step.Environment = map[string]string{}
+		}
+
+		if step.Stage != schema.StepStageChore {
+			maps.Copy(step.Environment, jobEnv)
+		}
+
+		if job.Chore.SourceConfig.ExposePlatformToken {
+			step.Environment["TEDIUM_PLATFORM_TOKEN"] = auth.Token()
+		}
+
+		steps[i] = step
+	}
+	job.ExecutionSteps = steps
+
+	return job, nil
+}
@@ -0,0 +1,72 @@
+package executor
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/markormesher/tedium/internal/platforms"
+	"github.com/markormesher/tedium/internal/schema"
+)
+
+func TestWithFreshAuth(t *testing.T) {
+	platformConfig := schema.PlatformConfig{
+		Type:    "gitea",
+		BaseURL: "https://gitea.auth-test.example.com",
+		Auth:    &schema.AuthConfig{Type: schema.AuthConfigTypeUserToken, TokenString: "secret-token"},
+	}
+	_, err := platforms.FromConfig(schema.TediumConfig{}, platformConfig)
+	if err != nil {
+		t.Fatalf("error building platform: %v", err)
+	}
+
+	// prepared jobs don't carry any credentials
+	job := schema.Job{
+		PlatformConfig: schema.PlatformConfig{Type: "gitea", BaseURL: platformConfig.BaseURL},
+		Repo:           schema.Repo{OwnerName: "owner", Name: "repo", Auth: schema.RepoAuth{Username: "x-access-token"}},
+		Chore:          schema.ChoreSpec{Name: "lint", SourceConfig: schema.RepoChoreConfig{ExposePlatformToken: true}},
+		ExecutionSteps: []schema.ExecutionStep{
+			{Label: "step-1", Stage: schema.StepStageClone},
+			{Label: "step-2", Stage: schema.StepStageChore, Environment: map[string]string{"LEVEL": "strict"}},
+			{Label: "step-3", Stage: schema.StepStageFinalise},
+		},
+	}
+
+	got, err := withFreshAuth(job)
+	if err != nil {
+		t.Fatalf("withFreshAuth() error = %v", err)
+	}
+
+	if _, ok := got.ExecutionSteps[1].Environment["TEDIUM_JOB"]; ok {
+		t.Error("chore step was given the job bundle")
+	}
+
+	if got.ExecutionSteps[1].Environment["LEVEL"] != "strict" {
+		t.Errorf("chore step lost its own environment: %v", got.ExecutionSteps[1].Environment)
+	}
+
+	for _, step := range got.ExecutionSteps {
+		if step.Environment["TEDIUM_PLATFORM_TOKEN"] != "secret-token" {
+			t.Errorf("%s has TEDIUM_PLATFORM_TOKEN %q, want the platform token", step.Label, step.Environment["TEDIUM_PLATFORM_TOKEN"])
+		}
+	}
+
+	// the internal steps read everything they need from the bundle
+	var bundled schema.Job
+	err = json.Unmarshal([]byte(got.ExecutionSteps[2].Environment["TEDIUM_JOB"]), &bundled)
+	if err != nil {
+		t.Fatalf("error decoding job bundle: %v", err)
+	}
+
+	if bundled.Repo.Auth.Password != "secret-token" || bundled.PlatformConfig.Auth.Token() != "secret-token" {
+		t.Errorf("job bundle has repo password %q and platform token %q, want the platform token", bundled.Repo.Auth.Password, bundled.PlatformConfig.Auth.Token())
+	}
+
+	if len(bundled.ExecutionSteps) != 0 {
+		t.Errorf("job bundle includes %d execution steps", len(bundled.ExecutionSteps))
+	}
+
+	// later attempts start from the original job, so it must be left without credentials
+	if job.Repo.Auth.Password != "" || job.PlatformConfig.Auth != nil || job.ExecutionSteps[0].Environment != nil || len(job.ExecutionSteps[1].Environment) != 1 {
+		t.Errorf("withFreshAuth() modified the job it was given: %+v", job)
+	}
+}
@@ -0,0 +1,59 @@
+package executor
+
+import (
+	"errors"
+
+	"github.com/markormesher/tedium/internal/schema"
+)
+
+// jobError attaches a failure class to an error so the worker can decide whether to retry it.
+type jobError struct {
+	class string
+	err   error
+}
+
+func (e *jobError) Error() string {
+	return e.err.Error()
+}
+
+func (e *jobError) Unwrap() error {
+	return e.err
+}
+
+func withFailureClass(class string, err error) error {
+	return &jobError{class: class, err: err}
+}
+
+// failureClass returns the class of a failed job's error. Errors that weren't classified when they were raised are assumed to be infrastructure problems.
+func failureClass(err error) string {
+	if errors.Is(err, errJobTimedOut) {
+		return schema.FailureClassTimeout
+	}
+
+	var jobErr *jobError
+	if errors.As(err, &jobErr) {
+		return jobErr.class
+	}
+
+	return schema.FailureClassInfrastructure
+}
+
+// failureClassForStep maps a failed step to a failure class based on the stage of the chore it belongs to.
+func failureClassForStep(job schema.Job, label string) string {
+	for _, step := range job.ExecutionSteps {
+		if step.Label != label {
+			continue
+		}
+
+		switch step.Stage {
+		case schema.StepStageClone:
+			return schema.FailureClassClone
+		case schema.StepStageFinalise:
+			return schema.FailureClassFinalise
+		default:
+			return schema.FailureClassChore
+		}
+	}
+
+	return schema.FailureClassInfrastructure
+}
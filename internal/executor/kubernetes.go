@@ -2,39 +2,64 @@ package executor
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"sync"
 	"time"
 
+	"github.com/markormesher/tedium/internal/platforms"
 	"github.com/markormesher/tedium/internal/schema"
+	"github.com/markormesher/tedium/internal/tracing"
 	"github.com/markormesher/tedium/internal/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8s "k8s.io/client-go/kubernetes"
 	batchclients "k8s.io/client-go/kubernetes/typed/batch/v1"
+	coreclients "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
-var k8sExecutorContext = context.TODO()
-
 type KubernetesExecutor struct {
+	// ctx is cancelled when Tedium is shutting down; no new chores are started after that point
+	ctx        context.Context
 	conf       schema.TediumConfig
 	jobQueue   <-chan schema.Job
 	eventQueue chan<- schema.Event
 
-	jobClient batchclients.JobInterface
+	jobClient   batchclients.JobInterface
+	podClient   coreclients.PodInterface
+	jobWatcher  *jobWatcher
+	targetLocks targetLocks
+	workerWg    sync.WaitGroup
 }
 
-func CreateAndStart(conf schema.TediumConfig, jobQueue <-chan schema.Job, eventQueue chan<- schema.Event) error {
+// errJobTimedOut is wrapped by errors from jobs that hit the chore timeout or a step timeout.
+var errJobTimedOut = errors.New("job timed out")
+
+// stepTimeoutCheckInterval is how often running steps are checked against their timeouts. Steps may overrun by up to this much.
+const stepTimeoutCheckInterval = 5 * time.Second
+
+// failedStepLogLines is how much of a failed step's output is fetched for reporting.
+const failedStepLogLines = 50
+
+// timeoutGracePeriod is added on top of Kubernetes' own deadline before we stop waiting on a job ourselves.
+const timeoutGracePeriod = 30 * time.Second
+
+func CreateAndStart(ctx context.Context, conf schema.TediumConfig, jobQueue <-chan schema.Job, eventQueue chan<- schema.Event) (*KubernetesExecutor, error) {
 	if conf.Executor.Kubernetes.Namespace == "" {
 		slog.Warn("kubernetes executor namespace was blank - using 'default'")
 		conf.Executor.Kubernetes.Namespace = "default"
 	}
 
-	e := KubernetesExecutor{
+	e := &KubernetesExecutor{
+		ctx:        ctx,
 		conf:       conf,
 		jobQueue:   jobQueue,
 		eventQueue: eventQueue,
@@ -46,62 +71,229 @@ func CreateAndStart(conf schema.TediumConfig, jobQueue <-chan schema.Job, eventQ
 	if e.conf.Executor.Kubernetes.KubeconfigPath != "" {
 		kubeConfig, err = clientcmd.BuildConfigFromFlags("", e.conf.Executor.Kubernetes.KubeconfigPath)
 		if err != nil {
-			return fmt.Errorf("error creating Kube config from provided path: %w", err)
+			return nil, fmt.Errorf("error creating Kube config from provided path: %w", err)
 		}
 	} else {
 		slog.Info("no kubeconfig path provided - attempting to use in-cluster config")
 		kubeConfig, err = rest.InClusterConfig()
 		if err != nil {
-			return fmt.Errorf("error creating Kube config in-cluster config: %w", err)
+			return nil, fmt.Errorf("error creating Kube config in-cluster config: %w", err)
 		}
 	}
 
 	clientSet, err := k8s.NewForConfig(kubeConfig)
 	if err != nil {
-		return fmt.Errorf("error creating new Kubernetes client: %w", err)
+		return nil, fmt.Errorf("error creating new Kubernetes client: %w", err)
 	}
 
 	e.jobClient = clientSet.BatchV1().Jobs(e.conf.Executor.Kubernetes.Namespace)
+	e.podClient = clientSet.CoreV1().Pods(e.conf.Executor.Kubernetes.Namespace)
+
+	e.jobWatcher, err = startJobWatcher(ctx, clientSet, e.conf.Executor.Kubernetes.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("error watching executor jobs: %w", err)
+	}
+
+	err = e.reconcileOrphanedJobs()
+	if err != nil {
+		return nil, err
+	}
 
 	// start workers
-	workerWg := sync.WaitGroup{}
 	for range conf.Executor.ChoreConcurrency {
-		workerWg.Go(func() { e.worker() })
+		e.workerWg.Go(func() { e.worker() })
 	}
 
-	return nil
+	return e, nil
+}
+
+// Wait blocks until every worker has stopped, which happens when the job queue is closed or the executor's context is cancelled. If the executor was cancelled, jobs from this run that are still running are cleaned up if configured.
+func (e *KubernetesExecutor) Wait() {
+	e.workerWg.Wait()
+
+	if e.ctx.Err() != nil && e.conf.Executor.Kubernetes.DeleteJobsOnShutdown {
+		e.deleteRunJobs()
+	}
 }
 
 func (e *KubernetesExecutor) worker() {
-	for job := range e.jobQueue {
-		err := e.executeChore(job)
-		if err != nil {
+	for {
+		var job schema.Job
+		select {
+		case <-e.ctx.Done():
+			return
+
+		case j, ok := <-e.jobQueue:
+			if !ok {
+				return
+			}
+			job = j
+		}
+
+		if e.ctx.Err() != nil {
+			// the job arrived at the same time as cancellation
+			return
+		}
+
+		result := schema.NewJobResult(job, e.conf.RunID)
+		e.eventQueue <- schema.NewJobEvent(schema.JobStarted, result)
+
+		// attempts are traced within the job's span, which was started when the job was prepared
+		ctx := e.ctx
+		if job.Span != nil {
+			ctx = trace.ContextWithSpan(ctx, job.Span)
+		}
+
+		err := e.executeChoreWithRetries(ctx, job, &result)
+		result.FinishedAt = time.Now()
+
+		switch {
+		case err == nil:
+			result.Status = schema.JobStatusSucceeded
+			e.eventQueue <- schema.NewJobEvent(schema.JobSucceeded, result)
+
+		case errors.Is(err, errJobTimedOut):
+			slog.Error("chore timed out", "repo", job.Repo.Name, "chore", job.Chore.Name, "error", err)
+			result.Status = schema.JobStatusTimedOut
+			result.Error = err.Error()
+			result.FailureClass = failureClass(err)
+			e.eventQueue <- schema.NewJobEvent(schema.JobTimedOut, result)
+			e.setFailedStatus(ctx, job, "Chore timed out")
+
+		default:
 			slog.Error("chore failed", "repo", job.Repo.Name, "chore", job.Chore.Name, "error", err)
-			e.eventQueue <- schema.JobFailed
-		} else {
-			e.eventQueue <- schema.JobSucceeded
+			result.Status = schema.JobStatusFailed
+			result.Error = err.Error()
+			result.FailureClass = failureClass(err)
+			e.eventQueue <- schema.NewJobEvent(schema.JobFailed, result)
+			e.setFailedStatus(ctx, job, "Chore failed")
+		}
+
+		if job.Span != nil {
+			job.Span.SetAttributes(
+				attribute.String("tedium.job.status", result.Status),
+				attribute.Int("tedium.job.attempts", result.Attempts),
+			)
+			if result.PullRequestURL != "" {
+				job.Span.SetAttributes(attribute.String("tedium.pull_request.url", result.PullRequestURL))
+			}
+			tracing.End(job.Span, err)
+		}
+	}
+}
+
+// setFailedStatus marks an existing PR branch as no longer up to date with the chore. Failures are logged rather than returned, because the job has already failed.
+func (e *KubernetesExecutor) setFailedStatus(ctx context.Context, job schema.Job, description string) {
+	if !e.conf.CommitStatuses.Enabled {
+		return
+	}
+
+	platform := platforms.FromURL(job.PlatformConfig.BaseURL)
+	if platform == nil {
+		slog.Warn("unable to retrieve platform to set commit status", "baseURL", job.PlatformConfig.BaseURL)
+		return
+	}
+
+	_, span := tracing.Start(ctx, "platform.SetCommitStatus")
+	err := platforms.SetJobStatus(platform, job, schema.CommitStatusFailure, description)
+	tracing.End(span, err)
+	if err != nil {
+		slog.Warn("error setting commit status", "repo", job.Repo.FullName(), "chore", job.Chore.Name, "error", err)
+	}
+}
+
+// executeChoreWithRetries runs a chore, retrying it with exponential backoff if it fails in a way that is configured to be retried.
+func (e *KubernetesExecutor) executeChoreWithRetries(ctx context.Context, job schema.Job, result *schema.JobResult) error {
+	retryConf := e.conf.Executor.Retries
+	backoff := time.Duration(retryConf.BackoffSeconds) * time.Second
+
+	for attempt := 1; ; attempt++ {
+		result.Attempts = attempt
+		err := e.claimAndExecuteChore(ctx, job, result)
+		if err == nil {
+			if attempt > 1 {
+				slog.Info("chore succeeded after retrying", "repo", job.Repo.FullName(), "chore", job.Chore.Name, "attempts", attempt)
+			}
+			return nil
 		}
 
-		time.Sleep(5 * time.Second)
+		class := failureClass(err)
+		if e.ctx.Err() != nil || attempt > retryConf.Count || !retryConf.ShouldRetry(class) {
+			if attempt > 1 {
+				return fmt.Errorf("chore failed after %d attempts: %w", attempt, err)
+			}
+			return err
+		}
+
+		slog.Warn("chore failed - retrying", "repo", job.Repo.FullName(), "chore", job.Chore.Name, "attempt", attempt, "failureClass", class, "backoff", backoff, "error", err)
+		retried := schema.NewJobEvent(schema.JobRetried, *result)
+		retried.Error = err.Error()
+		retried.Reason = class
+		e.eventQueue <- retried
+
+		select {
+		case <-e.ctx.Done():
+			return fmt.Errorf("context cancelled while waiting to retry chore: %w", err)
+
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+	}
+}
+
+// claimAndExecuteChore runs a chore once no other job is working on the same repo and chore.
+func (e *KubernetesExecutor) claimAndExecuteChore(ctx context.Context, job schema.Job, result *schema.JobResult) (err error) {
+	ctx, span := tracing.Start(ctx, "job.attempt", attribute.Int("tedium.job.attempt", result.Attempts))
+	defer func() { tracing.End(span, err) }()
+
+	release, err := e.claimTarget(ctx, job)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(ctx, e.choreTimeout(job)+timeoutGracePeriod)
+	defer cancel()
+
+	return e.executeChore(ctx, job, result)
+}
+
+func (e *KubernetesExecutor) choreTimeout(job schema.Job) time.Duration {
+	if job.Chore.TimeoutSeconds > 0 {
+		return time.Duration(job.Chore.TimeoutSeconds) * time.Second
 	}
+
+	return time.Duration(e.conf.Executor.Kubernetes.ChoreTimeoutSeconds) * time.Second
 }
 
-func (e *KubernetesExecutor) executeChore(job schema.Job) error {
+// executeChore runs a single attempt at a chore, recording details of the attempt in the result.
+func (e *KubernetesExecutor) executeChore(ctx context.Context, job schema.Job, result *schema.JobResult) error {
 	jobName := utils.UniqueName("executor")
+	result.ExecutorJobName = jobName
+	result.FailedStep = ""
+	result.Logs = ""
+
+	job, err := withFreshAuth(job)
+	if err != nil {
+		return err
+	}
+
 	k8sJob := &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
-			Namespace: e.conf.Executor.Kubernetes.Namespace,
-			Name:      jobName,
+			Namespace:   e.conf.Executor.Kubernetes.Namespace,
+			Name:        jobName,
+			Labels:      e.jobLabels(job),
+			Annotations: e.jobAnnotations(job),
 		},
 		Spec: batchv1.JobSpec{
 			BackoffLimit:            new(int32(0)),
+			ActiveDeadlineSeconds:   new(int64(e.choreTimeout(job).Seconds())),
 			TTLSecondsAfterFinished: new(int32(e.conf.Executor.Kubernetes.JobTTLSeconds)),
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: map[string]string{
-						"app.kubernetes.io/name":      "tedium",
-						"app.kubernetes.io/component": "executor",
-					},
+					Labels:      e.jobLabels(job),
+					Annotations: e.jobAnnotations(job),
 				},
 				Spec: corev1.PodSpec{
 					RestartPolicy:                 corev1.RestartPolicyNever,
@@ -127,76 +319,359 @@ func (e *KubernetesExecutor) executeChore(job schema.Job) error {
 		},
 	}
 
+	var extraMounts []corev1.VolumeMount
+	if len(job.Chore.Caches) > 0 {
+		mounts, err := cacheMounts(job.Chore)
+		if err != nil {
+			return withFailureClass(schema.FailureClassConfig, fmt.Errorf("error preparing chore caches: %w", err))
+		}
+
+		extraMounts = append(extraMounts, mounts...)
+		k8sJob.Spec.Template.Spec.Volumes = append(k8sJob.Spec.Template.Spec.Volumes, cacheVolume(e.conf.Executor.Kubernetes.Caches))
+	}
+
 	for _, step := range job.ExecutionSteps {
 		container := corev1.Container{
-			Name:    step.Label,
-			Image:   step.Image,
-			Env:     k8sEnvFromMap(step.Environment),
-			Command: []string{"/bin/sh", "-c"},
-			Args:    []string{"echo \"${TEDIUM_COMMAND}\" | /bin/sh"},
-			VolumeMounts: []corev1.VolumeMount{
+			Name:                     step.Label,
+			TerminationMessagePath:   schema.TerminationMessagePath,
+			TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+			Image:                    step.Image,
+			Env:                      k8sEnvFromMap(step.Environment),
+			Command:                  []string{"/bin/sh", "-c"},
+			Args:                     []string{"echo \"${TEDIUM_COMMAND}\" | /bin/sh"},
+			VolumeMounts: append([]corev1.VolumeMount{
 				{
 					Name:      "repo",
 					MountPath: "/tedium/repo",
 				},
-			},
+			}, extraMounts...),
 		}
 
 		k8sJob.Spec.Template.Spec.InitContainers = append(k8sJob.Spec.Template.Spec.InitContainers, container)
 	}
 
+	podConf := e.conf.Executor.Kubernetes.Pod.WithOverrides(job.Chore.Kubernetes)
+	err = applyPodConfig(&k8sJob.Spec.Template.Spec, podConf)
+	if err != nil {
+		return withFailureClass(schema.FailureClassConfig, fmt.Errorf("error applying pod config: %w", err))
+	}
+
+	// register with the watcher before the job exists so we can't miss it finishing
+	finished := e.jobWatcher.watch(jobName)
+	defer e.jobWatcher.unwatch(jobName)
+
 	// start the job
 	slog.Info("starting job", "repo", job.Repo.FullName(), "chore", job.Chore.Name, "job", k8sJob.GetName())
-	_, err := e.jobClient.Create(k8sExecutorContext, k8sJob, metav1.CreateOptions{})
+	_, err = e.jobClient.Create(ctx, k8sJob, metav1.CreateOptions{})
 	if err != nil {
 		return fmt.Errorf("error creating execution job: %w", err)
 	}
 
-	// track job to completion
-	ticker := time.NewTicker(5 * time.Second)
-	defer ticker.Stop()
+	// step timeouts are enforced here rather than inside the containers, so chore images don't need any particular tools
+	var stepTimeoutCheck <-chan time.Time
+	if slices.ContainsFunc(job.ExecutionSteps, func(step schema.ExecutionStep) bool { return step.TimeoutSeconds > 0 }) {
+		ticker := time.NewTicker(stepTimeoutCheckInterval)
+		defer ticker.Stop()
+		stepTimeoutCheck = ticker.C
+	}
+
+	// wait for the job to finish
+	var outcome jobOutcome
+waitForJob:
 	for {
 		select {
-		case <-k8sExecutorContext.Done():
-			return fmt.Errorf("context cancelled while waiting for job %q: %w", k8sJob.Name, k8sExecutorContext.Err())
+		case <-ctx.Done():
+			return e.handleCancelledJob(job, jobName)
 
-		case <-ticker.C:
-			j, err := e.jobClient.Get(k8sExecutorContext, k8sJob.Name, metav1.GetOptions{})
-			if err != nil {
-				return fmt.Errorf("lost track of job %q: %w", k8sJob.Name, err)
+		case <-stepTimeoutCheck:
+			step := e.findOverrunStep(ctx, job, jobName, time.Now())
+			if step == nil {
+				continue
 			}
 
-			for _, cond := range j.Status.Conditions {
-				if cond.Status != corev1.ConditionTrue {
+			e.deleteRunningJob(jobName)
+			slog.Error("chore step timed out", "repo", job.Repo.FullName(), "chore", job.Chore.Name, "job", jobName, "step", step.label, "logs", step.logs)
+			result.FailedStep = step.label
+			result.Logs = step.logs
+			return fmt.Errorf("%w: job %q step %q exceeded its timeout", errJobTimedOut, jobName, step.label)
+
+		case outcome = <-finished:
+			break waitForJob
+		}
+	}
+
+	if outcome.imagePullError != "" {
+		// the job would otherwise sit in a back-off loop until its deadline
+		e.deleteRunningJob(jobName)
+		return withFailureClass(schema.FailureClassImagePull, fmt.Errorf("job %q failed: %s", jobName, outcome.imagePullError))
+	}
+
+	j := outcome.job
+	if j == nil {
+		return fmt.Errorf("job %q was deleted before it finished", jobName)
+	}
+
+	e.recordStepSpans(ctx, jobName)
+
+	for _, cond := range j.Status.Conditions {
+		if cond.Status != corev1.ConditionTrue {
+			continue
+		}
+
+		switch cond.Type {
+		case batchv1.JobComplete:
+			slog.Info("job finished", "repo", job.Repo.FullName(), "chore", job.Chore.Name)
+
+			finaliseResult := e.readFinaliseResult(ctx, job, jobName)
+			result.PullRequestNumber = finaliseResult.PullRequestNumber
+			result.PullRequestURL = finaliseResult.PullRequestURL
+			result.PullRequestCreated = finaliseResult.PullRequestCreated
+
+			if e.conf.Executor.Kubernetes.DeleteSuccessfulJobs {
+				backgroundDelete := metav1.DeletePropagationBackground
+				err := e.jobClient.Delete(ctx, jobName, metav1.DeleteOptions{
+					PropagationPolicy: &backgroundDelete,
+				})
+				if err != nil {
+					slog.Warn("error deleting successful job", "repo", job.Repo.FullName(), "chore", job.Chore.Name, "error", err)
+				}
+			}
+
+			return nil
+
+		case batchv1.JobFailed:
+			if cond.Reason == batchv1.JobReasonDeadlineExceeded {
+				return fmt.Errorf("%w: job %q exceeded its deadline of %v", errJobTimedOut, jobName, e.choreTimeout(job))
+			}
+
+			step := e.findFailedStep(ctx, jobName)
+			if step == nil {
+				return fmt.Errorf("job %q failed: %s: %s", jobName, cond.Reason, cond.Message)
+			}
+
+			slog.Error("chore step failed", "repo", job.Repo.FullName(), "chore", job.Chore.Name, "job", jobName, "step", step.label, "exitCode", step.exitCode, "logs", step.logs)
+			result.FailedStep = step.label
+			result.Logs = step.logs
+
+			return withFailureClass(failureClassForStep(job, step.label), fmt.Errorf("job %q failed at step %q with exit code %d", jobName, step.label, step.exitCode))
+		}
+	}
+
+	// the watcher only reports jobs that have one of the conditions above
+	return fmt.Errorf("job %q finished in an unknown state", jobName)
+}
+
+// handleCancelledJob is called when we stop waiting on a job, either because Tedium is shutting down or because the job overran its timeout.
+func (e *KubernetesExecutor) handleCancelledJob(job schema.Job, jobName string) error {
+	if e.ctx.Err() != nil {
+		// jobs are cleaned up in bulk once all workers have stopped
+		if !e.conf.Executor.Kubernetes.DeleteJobsOnShutdown {
+			slog.Warn("leaving job running after shutdown", "repo", job.Repo.FullName(), "chore", job.Chore.Name, "job", jobName)
+		}
+
+		return fmt.Errorf("context cancelled while waiting for job %q: %w", jobName, e.ctx.Err())
+	}
+
+	// Kubernetes should have already stopped the job at its deadline, but make sure it isn't left running
+	e.deleteRunningJob(jobName)
+	return fmt.Errorf("%w: gave up waiting for job %q after %v", errJobTimedOut, jobName, e.choreTimeout(job))
+}
+
+func (e *KubernetesExecutor) deleteRunningJob(jobName string) {
+	// the context used for the job is already cancelled, so give the deletion a short window of its own
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	slog.Info("deleting running job", "job", jobName)
+	backgroundDelete := metav1.DeletePropagationBackground
+	err := e.jobClient.Delete(ctx, jobName, metav1.DeleteOptions{
+		PropagationPolicy: &backgroundDelete,
+	})
+	if err != nil {
+		slog.Warn("error deleting running job", "job", jobName, "error", err)
+	}
+}
+
+// deleteRunJobs deletes every job created during this run, using the run ID label.
+func (e *KubernetesExecutor) deleteRunJobs() {
+	// the executor's context is already cancelled, so give the deletion a short window of its own
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	slog.Info("deleting jobs from this run after shutdown", "runID", e.conf.RunID)
+	backgroundDelete := metav1.DeletePropagationBackground
+	err := e.jobClient.DeleteCollection(ctx, metav1.DeleteOptions{
+		PropagationPolicy: &backgroundDelete,
+	}, metav1.ListOptions{
+		LabelSelector: e.runLabelSelector(),
+	})
+	if err != nil {
+		slog.Warn("error deleting jobs from this run", "runID", e.conf.RunID, "error", err)
+	}
+}
+
+// failedStep describes the step that caused a job to fail, as far as we can tell from its pod.
+type failedStep struct {
+	label    string
+	exitCode int32
+	logs     string
+}
+
+// findFailedStep inspects a failed job's pod to find the step that failed, along with the tail of its logs.
+func (e *KubernetesExecutor) findFailedStep(ctx context.Context, jobName string) *failedStep {
+	pods, err := e.listJobPods(ctx, jobName)
+	if err != nil {
+		slog.Warn("error listing pods for failed job", "job", jobName, "error", err)
+		return nil
+	}
+
+	for _, pod := range pods {
+		for _, status := range pod.Status.InitContainerStatuses {
+			if status.State.Terminated == nil || status.State.Terminated.ExitCode == 0 {
+				continue
+			}
+
+			return &failedStep{
+				label:    status.Name,
+				exitCode: status.State.Terminated.ExitCode,
+				logs:     e.readStepLogs(ctx, pod.Name, status.Name),
+			}
+		}
+	}
+
+	return nil
+}
+
+// findOverrunStep inspects a running job's pod to find a step that had been running for longer than its timeout at the given time, along with the tail of its logs. Pods are read from the watch cache, because this is called repeatedly for every running job.
+func (e *KubernetesExecutor) findOverrunStep(ctx context.Context, job schema.Job, jobName string, now time.Time) *failedStep {
+	pods, err := e.jobWatcher.jobPods(jobName)
+	if err != nil {
+		slog.Warn("error listing pods to check step timeouts", "job", jobName, "error", err)
+		return nil
+	}
+
+	for _, pod := range pods {
+		for _, status := range pod.Status.InitContainerStatuses {
+			if status.State.Running == nil {
+				continue
+			}
+
+			for _, step := range job.ExecutionSteps {
+				if step.Label != status.Name || step.TimeoutSeconds <= 0 {
 					continue
 				}
 
-				switch cond.Type {
-				case batchv1.JobComplete:
-					slog.Info("job finished", "repo", job.Repo.FullName(), "chore", job.Chore.Name)
-
-					if e.conf.Executor.Kubernetes.DeleteSuccessfulJobs {
-						backgroundDelete := metav1.DeletePropagationBackground
-						err := e.jobClient.Delete(k8sExecutorContext, k8sJob.Name, metav1.DeleteOptions{
-							PropagationPolicy: &backgroundDelete,
-						})
-						if err != nil {
-							slog.Warn("error deleting successful job", "repo", job.Repo.FullName(), "chore", job.Chore.Name, "error", err)
-						}
+				if now.Sub(status.State.Running.StartedAt.Time) > time.Duration(step.TimeoutSeconds)*time.Second {
+					return &failedStep{
+						label: status.Name,
+						logs:  e.readStepLogs(ctx, pod.Name, status.Name),
 					}
+				}
+			}
+		}
+	}
 
-					return nil
+	return nil
+}
 
-				case batchv1.JobFailed:
-					return fmt.Errorf("job %q failed: %s: %s", k8sJob.Name, cond.Reason, cond.Message)
-				}
+// readStepLogs returns the tail of a step's logs, or an empty string if they can't be read.
+func (e *KubernetesExecutor) readStepLogs(ctx context.Context, podName string, stepLabel string) string {
+	logs, err := e.podClient.GetLogs(podName, &corev1.PodLogOptions{
+		Container: stepLabel,
+		TailLines: new(int64(failedStepLogLines)),
+	}).DoRaw(ctx)
+	if err != nil {
+		slog.Warn("error reading logs for step", "pod", podName, "step", stepLabel, "error", err)
+		return ""
+	}
+
+	return string(logs)
+}
+
+// recordStepSpans adds a span for every step of a finished job, using the times reported by its containers. Steps that never started are not recorded.
+func (e *KubernetesExecutor) recordStepSpans(ctx context.Context, jobName string) {
+	if !trace.SpanFromContext(ctx).IsRecording() {
+		// avoid listing pods when tracing is disabled
+		return
+	}
+
+	pods, err := e.listJobPods(ctx, jobName)
+	if err != nil {
+		slog.Warn("error listing pods for tracing", "job", jobName, "error", err)
+		return
+	}
+
+	for _, pod := range pods {
+		for _, status := range pod.Status.InitContainerStatuses {
+			terminated := status.State.Terminated
+			if terminated == nil {
+				continue
+			}
+
+			var stepErr error
+			if terminated.ExitCode != 0 {
+				stepErr = fmt.Errorf("step exited with code %d", terminated.ExitCode)
 			}
 
-			// neither complete nor failed yet, keep polling
+			tracing.RecordSpan(ctx, "job.step", terminated.StartedAt.Time, terminated.FinishedAt.Time, stepErr,
+				attribute.String("tedium.step.label", status.Name),
+				attribute.String("tedium.step.image", status.Image),
+				attribute.Int("tedium.step.exit_code", int(terminated.ExitCode)),
+			)
 		}
 	}
 }
 
+// readFinaliseResult reads the result reported by a successful job's finalise step, if it has one.
+func (e *KubernetesExecutor) readFinaliseResult(ctx context.Context, job schema.Job, jobName string) schema.FinaliseResult {
+	var result schema.FinaliseResult
+
+	var finaliseLabel string
+	for _, step := range job.ExecutionSteps {
+		if step.Stage == schema.StepStageFinalise {
+			finaliseLabel = step.Label
+		}
+	}
+
+	if finaliseLabel == "" {
+		return result
+	}
+
+	pods, err := e.listJobPods(ctx, jobName)
+	if err != nil {
+		slog.Warn("error listing pods for finished job", "job", jobName, "error", err)
+		return result
+	}
+
+	for _, pod := range pods {
+		for _, status := range pod.Status.InitContainerStatuses {
+			if status.Name != finaliseLabel || status.State.Terminated == nil || status.State.Terminated.Message == "" {
+				continue
+			}
+
+			err := json.Unmarshal([]byte(status.State.Terminated.Message), &result)
+			if err != nil {
+				slog.Warn("error parsing finalise result", "job", jobName, "error", err)
+			}
+
+			return result
+		}
+	}
+
+	return result
+}
+
+func (e *KubernetesExecutor) listJobPods(ctx context.Context, jobName string) ([]corev1.Pod, error) {
+	pods, err := e.podClient.List(ctx, metav1.ListOptions{
+		LabelSelector: e.runLabelSelector() + "," + batchv1.JobNameLabel + "=" + jobName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pods.Items, nil
+}
+
 func k8sEnvFromMap(mapEnv map[string]string) []corev1.EnvVar {
 	env := make([]corev1.EnvVar, len(mapEnv))
 	envCount := 0
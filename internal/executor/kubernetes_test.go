@@ -0,0 +1,257 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/markormesher/tedium/internal/platforms"
+	"github.com/markormesher/tedium/internal/schema"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestFindOverrunStepUsesWatchCache(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const namespace = "tedium"
+	const jobName = "executor-abc"
+	startedAt := time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC)
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      jobName + "-xyz",
+			Labels:    map[string]string{"app.kubernetes.io/name": "tedium", "app.kubernetes.io/component": "executor", batchv1.JobNameLabel: jobName},
+		},
+		Status: corev1.PodStatus{
+			InitContainerStatuses: []corev1.ContainerStatus{
+				{Name: "step-1", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}}},
+				{Name: "step-2", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: metav1.NewTime(startedAt)}}},
+			},
+		},
+	}
+
+	clientSet := fake.NewClientset(pod)
+	watcher, err := startJobWatcher(ctx, clientSet, namespace)
+	if err != nil {
+		t.Fatalf("error starting watcher: %v", err)
+	}
+
+	e := &KubernetesExecutor{
+		podClient:  clientSet.CoreV1().Pods(namespace),
+		jobWatcher: watcher,
+	}
+
+	job := schema.Job{
+		ExecutionSteps: []schema.ExecutionStep{
+			{Label: "step-1", TimeoutSeconds: 10},
+			{Label: "step-2", TimeoutSeconds: 60},
+		},
+	}
+
+	// anything the informer needed has been fetched by now; checks must not make any more list calls
+	clientSet.ClearActions()
+
+	if step := e.findOverrunStep(ctx, job, jobName, startedAt.Add(30*time.Second)); step != nil {
+		t.Errorf("findOverrunStep() reported %q before its timeout", step.label)
+	}
+
+	step := e.findOverrunStep(ctx, job, jobName, startedAt.Add(2*time.Minute))
+	if step == nil || step.label != "step-2" {
+		t.Fatalf("findOverrunStep() = %+v, want step-2", step)
+	}
+
+	if step := e.findOverrunStep(ctx, job, "executor-other", startedAt.Add(2*time.Minute)); step != nil {
+		t.Errorf("findOverrunStep() reported %q for a different job", step.label)
+	}
+
+	for _, action := range clientSet.Actions() {
+		if action.GetVerb() == "list" {
+			t.Errorf("findOverrunStep() made an API request: %s %s", action.GetVerb(), action.GetResource().Resource)
+		}
+	}
+
+	// the pod's status changes are picked up from the watch, not by asking the API again
+	pod = pod.DeepCopy()
+	pod.Status.InitContainerStatuses[1].State = corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}}
+	_, err = clientSet.CoreV1().Pods(namespace).UpdateStatus(ctx, pod, metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatalf("error updating pod: %v", err)
+	}
+
+	synced := func() bool {
+		pods, _ := watcher.jobPods(jobName)
+		return len(pods) == 1 && pods[0].Status.InitContainerStatuses[1].State.Running == nil
+	}
+	syncCtx, syncCancel := context.WithTimeout(ctx, 5*time.Second)
+	defer syncCancel()
+	if !cache.WaitForCacheSync(syncCtx.Done(), synced) {
+		t.Fatal("watch cache did not see the pod update")
+	}
+
+	if step := e.findOverrunStep(ctx, job, jobName, startedAt.Add(2*time.Minute)); step != nil {
+		t.Errorf("findOverrunStep() reported %q after it finished", step.label)
+	}
+}
+
+// A chore whose pod config is invalid fails the same way on every attempt, so it must not use up the retries meant for flaky infrastructure.
+func TestInvalidPodConfigIsNotRetried(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const namespace = "tedium"
+	clientSet := fake.NewClientset()
+	watcher, err := startJobWatcher(ctx, clientSet, namespace)
+	if err != nil {
+		t.Fatalf("error starting watcher: %v", err)
+	}
+
+	conf := schema.TediumConfig{RunID: "run-1"}
+	conf.Executor.Kubernetes.Namespace = namespace
+	conf.Executor.Retries = schema.RetryConfig{Count: 3, RetryOn: []string{schema.FailureClassInfrastructure}}
+
+	eventQueue := make(chan schema.Event, 10)
+	e := &KubernetesExecutor{
+		ctx:        ctx,
+		conf:       conf,
+		eventQueue: eventQueue,
+		jobClient:  clientSet.BatchV1().Jobs(namespace),
+		podClient:  clientSet.CoreV1().Pods(namespace),
+		jobWatcher: watcher,
+	}
+
+	platformConfig := schema.PlatformConfig{Type: "gitea", BaseURL: "https://gitea.executor-test.example.com"}
+	_, err = platforms.FromConfig(conf, platformConfig)
+	if err != nil {
+		t.Fatalf("error building platform: %v", err)
+	}
+
+	job := schema.Job{
+		PlatformConfig: platformConfig,
+		Repo:           schema.Repo{OwnerName: "owner", Name: "repo"},
+		Chore:          schema.ChoreSpec{Name: "lint"},
+		ExecutionSteps: []schema.ExecutionStep{{Label: "step-1", Image: "alpine"}},
+	}
+	job.Chore.Kubernetes.Resources = &schema.KubernetesResourcesConfig{Limits: map[string]string{"memory": "lots"}}
+
+	result := &schema.JobResult{}
+	err = e.executeChoreWithRetries(ctx, job, result)
+	if err == nil {
+		t.Fatal("executeChoreWithRetries() succeeded with an invalid memory limit")
+	}
+
+	if class := failureClass(err); class != schema.FailureClassConfig {
+		t.Errorf("failureClass() = %q, want %q (error: %v)", class, schema.FailureClassConfig, err)
+	}
+
+	if result.Attempts != 1 || len(eventQueue) != 0 {
+		t.Errorf("chore was retried: %d attempts, %d retry events", result.Attempts, len(eventQueue))
+	}
+
+	for _, action := range clientSet.Actions() {
+		if action.GetVerb() == "create" {
+			t.Errorf("a %s was created for a chore with invalid config", action.GetResource().Resource)
+		}
+	}
+}
+
+// The hardened defaults come from loading the runtime config, and chores only add to them; both must end up on every container of the job that is created.
+func TestChorePodSecurity(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	configPath := filepath.Join(t.TempDir(), "config.yml")
+	err := os.WriteFile(configPath, []byte(`
+executor:
+  kubernetes:
+    namespace: tedium
+    pod:
+      securityContext:
+        runAsNonRoot: true
+        fsGroup: 1000
+        dropCapabilities: ["ALL"]
+    choreOverrides:
+      allowedCapabilities: ["NET_BIND_SERVICE"]
+`), 0o600)
+	if err != nil {
+		t.Fatalf("error writing config: %v", err)
+	}
+
+	conf, err := schema.LoadTediumConfig(configPath, "")
+	if err != nil {
+		t.Fatalf("LoadTediumConfig() error = %v", err)
+	}
+
+	platformConfig := schema.PlatformConfig{Type: "gitea", BaseURL: "https://gitea.security-test.example.com"}
+	_, err = platforms.FromConfig(conf, platformConfig)
+	if err != nil {
+		t.Fatalf("error building platform: %v", err)
+	}
+
+	// the created job is captured and creation fails, so the executor doesn't wait for a job that will never run
+	clientSet := fake.NewClientset()
+	var created *batchv1.Job
+	clientSet.PrependReactor("create", "jobs", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		created = action.(k8stesting.CreateAction).GetObject().(*batchv1.Job)
+		return true, nil, errors.New("not creating jobs in tests")
+	})
+
+	watcher, err := startJobWatcher(ctx, clientSet, conf.Executor.Kubernetes.Namespace)
+	if err != nil {
+		t.Fatalf("error starting watcher: %v", err)
+	}
+
+	e := &KubernetesExecutor{
+		ctx:        ctx,
+		conf:       conf,
+		jobClient:  clientSet.BatchV1().Jobs(conf.Executor.Kubernetes.Namespace),
+		podClient:  clientSet.CoreV1().Pods(conf.Executor.Kubernetes.Namespace),
+		jobWatcher: watcher,
+	}
+
+	job := schema.Job{
+		PlatformConfig: platformConfig,
+		Repo:           schema.Repo{OwnerName: "owner", Name: "repo"},
+		Chore:          schema.ChoreSpec{Name: "serve"},
+		ExecutionSteps: []schema.ExecutionStep{{Label: "step-1", Image: "alpine"}},
+	}
+	job.Chore.Kubernetes.SecurityContext.AddCapabilities = []string{"NET_BIND_SERVICE"}
+
+	_ = e.executeChore(ctx, job, &schema.JobResult{})
+	if created == nil {
+		t.Fatal("executeChore() did not create a job")
+	}
+
+	podSpec := created.Spec.Template.Spec
+	podSecurity := podSpec.SecurityContext
+	if podSecurity == nil || podSecurity.RunAsNonRoot == nil || !*podSecurity.RunAsNonRoot || podSecurity.FSGroup == nil || *podSecurity.FSGroup != 1000 {
+		t.Errorf("pod security context = %+v, want runAsNonRoot and fsGroup 1000 from the runtime config", podSecurity)
+	}
+
+	if podSecurity == nil || podSecurity.SeccompProfile == nil || podSecurity.SeccompProfile.Type != corev1.SeccompProfileTypeRuntimeDefault {
+		t.Errorf("pod seccomp profile = %+v, want the RuntimeDefault default", podSecurity)
+	}
+
+	for _, container := range append(podSpec.InitContainers, podSpec.Containers...) {
+		security := container.SecurityContext
+		if security == nil || security.AllowPrivilegeEscalation == nil || *security.AllowPrivilegeEscalation {
+			t.Errorf("container %s allows privilege escalation", container.Name)
+			continue
+		}
+
+		if security.Capabilities == nil || !slices.Equal(security.Capabilities.Drop, []corev1.Capability{"ALL"}) || !slices.Equal(security.Capabilities.Add, []corev1.Capability{"NET_BIND_SERVICE"}) {
+			t.Errorf("container %s has capabilities %+v, want ALL dropped and NET_BIND_SERVICE added", container.Name, security.Capabilities)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package executor
+
+import (
+	"maps"
+
+	"github.com/markormesher/tedium/internal/schema"
+	"github.com/markormesher/tedium/internal/utils"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// label values are restricted in length and character set, so full values are also recorded as annotations
+const (
+	labelRunID    = "tedium/run-id"
+	labelPlatform = "tedium/platform"
+	labelRepo     = "tedium/repo"
+	labelChore    = "tedium/chore"
+	labelTarget   = "tedium/target"
+
+	annotationRunID     = "tedium/run-id"
+	annotationVersion   = "tedium/version"
+	annotationPlatform  = "tedium/platform"
+	annotationRepo      = "tedium/repo"
+	annotationChoreName = "tedium/chore-name"
+	annotationChoreURL  = "tedium/chore-url"
+)
+
+// executorLabels are applied to every job (and its pod) created by the executor.
+var executorLabels = map[string]string{
+	"app.kubernetes.io/name":      "tedium",
+	"app.kubernetes.io/component": "executor",
+}
+
+var executorLabelSelector = labels.SelectorFromSet(executorLabels).String()
+
+func (e *KubernetesExecutor) jobLabels(job schema.Job) map[string]string {
+	jobLabels := map[string]string{}
+
+	// user-provided labels go first so they can't clobber the ones we rely on
+	maps.Copy(jobLabels, e.conf.Executor.Kubernetes.ExtraLabels)
+	maps.Copy(jobLabels, executorLabels)
+
+	jobLabels["app.kubernetes.io/version"] = utils.ConvertToLabelValue(e.conf.Version)
+	jobLabels[labelRunID] = utils.ConvertToLabelValue(e.conf.RunID)
+	jobLabels[labelPlatform] = utils.ConvertToLabelValue(job.PlatformConfig.BaseURL)
+	jobLabels[labelRepo] = utils.ConvertToLabelValue(job.Repo.FullName())
+	jobLabels[labelChore] = utils.ConvertToLabelValue(job.Chore.Name)
+	jobLabels[labelTarget] = targetKey(job)
+
+	return jobLabels
+}
+
+func (e *KubernetesExecutor) jobAnnotations(job schema.Job) map[string]string {
+	jobAnnotations := map[string]string{}
+	maps.Copy(jobAnnotations, e.conf.Executor.Kubernetes.ExtraAnnotations)
+
+	jobAnnotations[annotationRunID] = e.conf.RunID
+	jobAnnotations[annotationVersion] = e.conf.Version
+	jobAnnotations[annotationPlatform] = job.PlatformConfig.BaseURL
+	jobAnnotations[annotationRepo] = job.Repo.FullName()
+	jobAnnotations[annotationChoreName] = job.Chore.Name
+	jobAnnotations[annotationChoreURL] = job.Chore.SourceConfig.URL + "#" + job.Chore.SourceConfig.Directory
+
+	return jobAnnotations
+}
+
+// targetKey uniquely identifies the combination of a repo and a chore, which is used to find jobs that would work on the same branch.
+func targetKey(job schema.Job) string {
+	return utils.SHA256String(job.PlatformConfig.BaseURL + "#" + job.Repo.FullName() + "#" + job.Chore.SourceConfig.URL + "#" + job.Chore.SourceConfig.Directory)[:32]
+}
+
+func (e *KubernetesExecutor) runLabelSelector() string {
+	return labels.SelectorFromSet(map[string]string{
+		labelRunID: utils.ConvertToLabelValue(e.conf.RunID),
+	}).String()
+}
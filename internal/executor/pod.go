@@ -0,0 +1,220 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path"
+	"regexp"
+
+	"github.com/markormesher/tedium/internal/schema"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// applyPodConfig applies scheduling and resource settings to a chore pod. It must be called after all containers have been added.
+func applyPodConfig(podSpec *corev1.PodSpec, podConf schema.KubernetesPodConfig) error {
+	if podConf.Resources != nil {
+		requests, err := k8sResourceList(podConf.Resources.Requests)
+		if err != nil {
+			return fmt.Errorf("invalid resource requests: %w", err)
+		}
+
+		limits, err := k8sResourceList(podConf.Resources.Limits)
+		if err != nil {
+			return fmt.Errorf("invalid resource limits: %w", err)
+		}
+
+		resources := corev1.ResourceRequirements{
+			Requests: requests,
+			Limits:   limits,
+		}
+
+		for i := range podSpec.InitContainers {
+			podSpec.InitContainers[i].Resources = resources
+		}
+
+		for i := range podSpec.Containers {
+			podSpec.Containers[i].Resources = resources
+		}
+	}
+
+	podSpec.NodeSelector = podConf.NodeSelector
+
+	for _, t := range podConf.Tolerations {
+		podSpec.Tolerations = append(podSpec.Tolerations, corev1.Toleration{
+			Key:               t.Key,
+			Operator:          corev1.TolerationOperator(t.Operator),
+			Value:             t.Value,
+			Effect:            corev1.TaintEffect(t.Effect),
+			TolerationSeconds: t.TolerationSeconds,
+		})
+	}
+
+	if podConf.Affinity != nil {
+		// affinity is free-form in our config, so round-trip it through JSON to get the Kubernetes type
+		affinityJSON, err := json.Marshal(podConf.Affinity)
+		if err != nil {
+			return fmt.Errorf("invalid affinity: %w", err)
+		}
+
+		var affinity corev1.Affinity
+		err = json.Unmarshal(affinityJSON, &affinity)
+		if err != nil {
+			return fmt.Errorf("invalid affinity: %w", err)
+		}
+
+		podSpec.Affinity = &affinity
+	}
+
+	podSpec.PriorityClassName = podConf.PriorityClassName
+	podSpec.ServiceAccountName = podConf.ServiceAccountName
+
+	for _, secret := range podConf.ImagePullSecrets {
+		podSpec.ImagePullSecrets = append(podSpec.ImagePullSecrets, corev1.LocalObjectReference{
+			Name: secret,
+		})
+	}
+
+	return applySecurityConfig(podSpec, podConf.SecurityContext)
+}
+
+func applySecurityConfig(podSpec *corev1.PodSpec, securityConf schema.KubernetesSecurityConfig) error {
+	podSecurity := &corev1.PodSecurityContext{
+		RunAsNonRoot: securityConf.RunAsNonRoot,
+		RunAsUser:    securityConf.RunAsUser,
+		RunAsGroup:   securityConf.RunAsGroup,
+		FSGroup:      securityConf.FSGroup,
+	}
+
+	switch securityConf.SeccompProfile {
+	case "":
+		// leave it to the cluster
+
+	case string(corev1.SeccompProfileTypeRuntimeDefault), string(corev1.SeccompProfileTypeUnconfined):
+		podSecurity.SeccompProfile = &corev1.SeccompProfile{
+			Type: corev1.SeccompProfileType(securityConf.SeccompProfile),
+		}
+
+	default:
+		return fmt.Errorf("unsupported seccomp profile: %s", securityConf.SeccompProfile)
+	}
+
+	podSpec.SecurityContext = podSecurity
+
+	containerSecurity := corev1.SecurityContext{
+		ReadOnlyRootFilesystem:   securityConf.ReadOnlyRootFilesystem,
+		AllowPrivilegeEscalation: securityConf.AllowPrivilegeEscalation,
+	}
+
+	if securityConf.DropCapabilities != nil || securityConf.AddCapabilities != nil {
+		containerSecurity.Capabilities = &corev1.Capabilities{}
+		for _, c := range securityConf.DropCapabilities {
+			containerSecurity.Capabilities.Drop = append(containerSecurity.Capabilities.Drop, corev1.Capability(c))
+		}
+		for _, c := range securityConf.AddCapabilities {
+			containerSecurity.Capabilities.Add = append(containerSecurity.Capabilities.Add, corev1.Capability(c))
+		}
+	}
+
+	readOnlyRoot := securityConf.ReadOnlyRootFilesystem != nil && *securityConf.ReadOnlyRootFilesystem
+	if readOnlyRoot {
+		// most tools expect to be able to write temporary files somewhere
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name: "tmp",
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		})
+	}
+
+	containers := []*corev1.Container{}
+	for i := range podSpec.InitContainers {
+		containers = append(containers, &podSpec.InitContainers[i])
+	}
+	for i := range podSpec.Containers {
+		containers = append(containers, &podSpec.Containers[i])
+	}
+
+	for _, c := range containers {
+		c.SecurityContext = containerSecurity.DeepCopy()
+
+		if readOnlyRoot {
+			c.VolumeMounts = append(c.VolumeMounts, corev1.VolumeMount{
+				Name:      "tmp",
+				MountPath: "/tmp",
+			})
+		}
+	}
+
+	return nil
+}
+
+func k8sResourceList(mapResources map[string]string) (corev1.ResourceList, error) {
+	if len(mapResources) == 0 {
+		return nil, nil
+	}
+
+	resources := corev1.ResourceList{}
+	for k, v := range mapResources {
+		q, err := resource.ParseQuantity(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quantity for %s: %w", k, err)
+		}
+		resources[corev1.ResourceName(k)] = q
+	}
+
+	return resources, nil
+}
+
+const cacheVolumeName = "caches"
+
+var cacheNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.\-]*$`)
+
+// cacheVolume builds the volume that backs chore caches, falling back to a throwaway directory if no storage is configured.
+func cacheVolume(cacheConf schema.KubernetesCacheConfig) corev1.Volume {
+	volume := corev1.Volume{
+		Name: cacheVolumeName,
+	}
+
+	switch {
+	case cacheConf.PersistentVolumeClaim != "":
+		volume.PersistentVolumeClaim = &corev1.PersistentVolumeClaimVolumeSource{
+			ClaimName: cacheConf.PersistentVolumeClaim,
+		}
+
+	case cacheConf.HostPath != "":
+		volume.HostPath = &corev1.HostPathVolumeSource{
+			Path: cacheConf.HostPath,
+			Type: new(corev1.HostPathDirectoryOrCreate),
+		}
+
+	default:
+		slog.Warn("chore uses caches but no cache storage is configured - caches will not be persisted")
+		volume.EmptyDir = &corev1.EmptyDirVolumeSource{}
+	}
+
+	return volume
+}
+
+// cacheMounts builds a mount for each of a chore's caches. Each chore gets its own sub-directory of the cache volume, so caches are never shared between chores.
+func cacheMounts(chore schema.ChoreSpec) ([]corev1.VolumeMount, error) {
+	mounts := make([]corev1.VolumeMount, len(chore.Caches))
+	for i, cache := range chore.Caches {
+		if !cacheNameRegex.MatchString(cache.Name) {
+			return nil, fmt.Errorf("invalid cache name %q", cache.Name)
+		}
+
+		if !path.IsAbs(cache.MountPath) {
+			return nil, fmt.Errorf("cache %q must have an absolute mount path", cache.Name)
+		}
+
+		mounts[i] = corev1.VolumeMount{
+			Name:      cacheVolumeName,
+			MountPath: cache.MountPath,
+			SubPath:   path.Join(chore.CacheKey(), cache.Name),
+		}
+	}
+
+	return mounts, nil
+}
@@ -0,0 +1,153 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/markormesher/tedium/internal/schema"
+	"github.com/markormesher/tedium/internal/utils"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// reconcileOrphanedJobs finds jobs that are still running from previous runs of Tedium (e.g. if it crashed) and deletes them if configured to. Otherwise they are left alone, and any job that targets the same repo and chore will wait for them to finish.
+func (e *KubernetesExecutor) reconcileOrphanedJobs() error {
+	orphans, err := e.findActiveJobs(labels.Everything())
+	if err != nil {
+		return fmt.Errorf("error listing orphaned jobs: %w", err)
+	}
+
+	for _, orphan := range orphans {
+		slog.Warn("found running job from a previous run", "job", orphan.Name, "runID", orphan.Annotations[annotationRunID], "repo", orphan.Annotations[annotationRepo], "chore", orphan.Annotations[annotationChoreName])
+
+		if e.conf.Executor.Kubernetes.OrphanedJobPolicy == schema.OrphanedJobPolicyDelete {
+			e.deleteRunningJob(orphan.Name)
+		}
+	}
+
+	return nil
+}
+
+// findActiveJobs returns unfinished jobs matching the selector that were not created by this run.
+func (e *KubernetesExecutor) findActiveJobs(selector labels.Selector) ([]*batchv1.Job, error) {
+	jobs, err := e.jobWatcher.lister.List(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	runIDLabel := utils.ConvertToLabelValue(e.conf.RunID)
+
+	var active []*batchv1.Job
+	for _, job := range jobs {
+		if job.Labels[labelRunID] == runIDLabel || jobFinished(job) || job.DeletionTimestamp != nil {
+			continue
+		}
+
+		active = append(active, job)
+	}
+
+	return active, nil
+}
+
+// claimTarget blocks until no other job is working on the same repo and chore, then reserves them for the caller. The returned function must be called to release the reservation.
+func (e *KubernetesExecutor) claimTarget(ctx context.Context, job schema.Job) (func(), error) {
+	key := targetKey(job)
+
+	// first make sure no other worker in this run has the same target...
+	release, err := e.targetLocks.acquire(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	// ...then deal with any jobs from other runs
+	selector := labels.SelectorFromSet(map[string]string{labelTarget: key})
+	for {
+		others, err := e.findActiveJobs(selector)
+		if err != nil {
+			release()
+			return nil, fmt.Errorf("error checking for other jobs with the same target: %w", err)
+		}
+
+		if len(others) == 0 {
+			return release, nil
+		}
+
+		other := others[0]
+		if e.conf.Executor.Kubernetes.OrphanedJobPolicy == schema.OrphanedJobPolicyDelete {
+			slog.Warn("deleting job from a previous run with the same target", "repo", job.Repo.FullName(), "chore", job.Chore.Name, "job", other.Name)
+			e.deleteRunningJob(other.Name)
+		} else {
+			slog.Info("waiting for job from a previous run with the same target", "repo", job.Repo.FullName(), "chore", job.Chore.Name, "job", other.Name)
+		}
+
+		err = e.waitForJob(ctx, other.Name)
+		if err != nil {
+			release()
+			return nil, err
+		}
+	}
+}
+
+// waitForJob waits for a job that was not created by this worker to finish or be deleted.
+func (e *KubernetesExecutor) waitForJob(ctx context.Context, jobName string) error {
+	finished := e.jobWatcher.watch(jobName)
+	defer e.jobWatcher.unwatch(jobName)
+
+	// the job may have finished between being listed and the watch being registered
+	j, err := e.jobWatcher.lister.Get(jobName)
+	if err != nil || jobFinished(j) {
+		return nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("context cancelled while waiting for job %q: %w", jobName, ctx.Err())
+
+		case outcome := <-finished:
+			// a job stuck pulling an image isn't finished; its owner (or its deadline) will deal with it
+			if outcome.imagePullError == "" {
+				return nil
+			}
+		}
+	}
+}
+
+// targetLocks ensures that only one worker at a time runs a job for a given target.
+type targetLocks struct {
+	lock sync.Mutex
+	held map[string]chan struct{}
+}
+
+func (t *targetLocks) acquire(ctx context.Context, key string) (func(), error) {
+	for {
+		t.lock.Lock()
+		if t.held == nil {
+			t.held = map[string]chan struct{}{}
+		}
+
+		released, isHeld := t.held[key]
+		if !isHeld {
+			released = make(chan struct{})
+			t.held[key] = released
+			t.lock.Unlock()
+
+			return func() {
+				t.lock.Lock()
+				defer t.lock.Unlock()
+				delete(t.held, key)
+				close(released)
+			}, nil
+		}
+		t.lock.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("context cancelled while waiting for another job with the same target: %w", ctx.Err())
+
+		case <-released:
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	k8s "k8s.io/client-go/kubernetes"
+	batchlisters "k8s.io/client-go/listers/batch/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// imagePullFailureReasons are container waiting reasons that mean an image will not be pulled without intervention. ErrImagePull is not included because Kubernetes retries it.
+var imagePullFailureReasons = []string{"ImagePullBackOff", "InvalidImageName"}
+
+// jobOutcome is sent to a worker when the job it is waiting on stops making progress.
+type jobOutcome struct {
+	// job is set if the job finished (successfully or not)
+	job *batchv1.Job
+
+	// imagePullError is set if one of the job's containers cannot pull its image
+	imagePullError string
+
+	// if neither field is set, the job was deleted before it finished
+}
+
+// jobWatcher maintains a single watch on all Tedium executor jobs and their pods, and notifies workers when the job they are waiting on finishes.
+type jobWatcher struct {
+	lister    batchlisters.JobNamespaceLister
+	podLister corelisters.PodNamespaceLister
+
+	lock    sync.Mutex
+	waiters map[string]chan jobOutcome
+}
+
+func startJobWatcher(ctx context.Context, clientSet k8s.Interface, namespace string) (*jobWatcher, error) {
+	w := &jobWatcher{
+		waiters: map[string]chan jobOutcome{},
+	}
+
+	factory := informers.NewSharedInformerFactoryWithOptions(
+		clientSet,
+		0,
+		informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.LabelSelector = executorLabelSelector
+		}),
+	)
+
+	jobInformer := factory.Batch().V1().Jobs()
+	_, err := jobInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj any) {
+			w.onJobChanged(obj)
+		},
+		UpdateFunc: func(_, obj any) {
+			w.onJobChanged(obj)
+		},
+		DeleteFunc: func(obj any) {
+			w.onJobDeleted(obj)
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error registering job watch handler: %w", err)
+	}
+
+	podInformer := factory.Core().V1().Pods()
+	_, err = podInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj any) {
+			w.onPodChanged(obj)
+		},
+		UpdateFunc: func(_, obj any) {
+			w.onPodChanged(obj)
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error registering pod watch handler: %w", err)
+	}
+
+	w.lister = jobInformer.Lister().Jobs(namespace)
+	w.podLister = podInformer.Lister().Pods(namespace)
+
+	factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), jobInformer.Informer().HasSynced, podInformer.Informer().HasSynced) {
+		return nil, fmt.Errorf("error starting job watch: cache did not sync")
+	}
+
+	return w, nil
+}
+
+// watch registers interest in a job. It must be called before the job is created, so that a fast-finishing job cannot be missed. The returned channel receives the job's outcome once it stops making progress.
+func (w *jobWatcher) watch(jobName string) <-chan jobOutcome {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	ch := make(chan jobOutcome, 1)
+	w.waiters[jobName] = ch
+	return ch
+}
+
+func (w *jobWatcher) unwatch(jobName string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	delete(w.waiters, jobName)
+}
+
+// jobPods returns a job's pods from the watch cache, so that checking on running jobs doesn't need any API requests.
+func (w *jobWatcher) jobPods(jobName string) ([]*corev1.Pod, error) {
+	return w.podLister.List(labels.SelectorFromSet(labels.Set{batchv1.JobNameLabel: jobName}))
+}
+
+func (w *jobWatcher) onJobChanged(obj any) {
+	job, ok := obj.(*batchv1.Job)
+	if !ok || !jobFinished(job) {
+		return
+	}
+
+	w.notify(job.Name, jobOutcome{job: job})
+}
+
+func (w *jobWatcher) onJobDeleted(obj any) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
+	job, ok := obj.(*batchv1.Job)
+	if !ok {
+		return
+	}
+
+	if jobFinished(job) {
+		w.notify(job.Name, jobOutcome{job: job})
+	} else {
+		w.notify(job.Name, jobOutcome{})
+	}
+}
+
+func (w *jobWatcher) onPodChanged(obj any) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return
+	}
+
+	jobName := pod.Labels[batchv1.JobNameLabel]
+	if jobName == "" {
+		return
+	}
+
+	statuses := slices.Concat(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses)
+	for _, status := range statuses {
+		if status.State.Waiting != nil && slices.Contains(imagePullFailureReasons, status.State.Waiting.Reason) {
+			w.notify(jobName, jobOutcome{
+				imagePullError: fmt.Sprintf("container %q cannot pull image %q: %s", status.Name, status.Image, status.State.Waiting.Message),
+			})
+			return
+		}
+	}
+}
+
+func (w *jobWatcher) notify(jobName string, outcome jobOutcome) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	ch, ok := w.waiters[jobName]
+	if !ok {
+		return
+	}
+
+	// only the first outcome matters; later updates (e.g. deletion after success) are dropped
+	select {
+	case ch <- outcome:
+	default:
+		slog.Debug("dropping repeated job outcome", "job", jobName)
+	}
+}
+
+func jobFinished(job *batchv1.Job) bool {
+	for _, cond := range job.Status.Conditions {
+		if cond.Status == corev1.ConditionTrue && (cond.Type == batchv1.JobComplete || cond.Type == batchv1.JobFailed) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,167 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/markormesher/tedium/internal/schema"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// Registry holds every metric that Tedium reports. A dedicated registry is used so that pushed metrics only describe Tedium's work, not the Go runtime.
+var Registry = prometheus.NewRegistry()
+
+var (
+	reposDiscovered = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tedium_repos_discovered_total",
+		Help: "Repos discovered on each platform.",
+	}, []string{"platform"})
+
+	reposSkipped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tedium_repos_skipped_total",
+		Help: "Repos skipped, e.g. because they are archived or have no Tedium config.",
+	}, []string{"platform"})
+
+	reposFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tedium_repos_failed_total",
+		Help: "Repos that could not be checked or whose config could not be resolved.",
+	}, []string{"platform"})
+
+	jobs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tedium_jobs_total",
+		Help: "Jobs that finished, by chore and outcome.",
+	}, []string{"chore", "outcome"})
+
+	jobRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tedium_job_retries_total",
+		Help: "Job attempts that failed and were retried, by chore and failure class.",
+	}, []string{"chore", "failure_class"})
+
+	jobDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "tedium_job_duration_seconds",
+		Help:    "Time taken by jobs, including any retries, by chore and outcome.",
+		Buckets: prometheus.ExponentialBuckets(10, 2, 10),
+	}, []string{"chore", "outcome"})
+
+	runs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tedium_runs_total",
+		Help: "Runs that finished, by outcome.",
+	}, []string{"outcome"})
+
+	lastRunDuration = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "tedium_last_run_duration_seconds",
+		Help: "Time taken by the most recent run.",
+	})
+
+	lastRunFinished = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "tedium_last_run_finished_timestamp_seconds",
+		Help: "Unix time at which the most recent run finished.",
+	})
+
+	platformRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tedium_platform_requests_total",
+		Help: "Requests made to platform APIs, by platform, method and status code. Network errors are reported with a status code of \"error\".",
+	}, []string{"platform", "method", "status_code"})
+
+	platformRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "tedium_platform_request_duration_seconds",
+		Help:    "Time taken by requests to platform APIs, by platform and method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"platform", "method"})
+
+	platformRateLimitRemaining = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "tedium_platform_rate_limit_remaining",
+		Help: "Requests remaining in the current rate limit window, as last reported by each platform.",
+	}, []string{"platform"})
+)
+
+func init() {
+	Registry.MustRegister(
+		reposDiscovered,
+		reposSkipped,
+		reposFailed,
+		jobs,
+		jobRetries,
+		jobDuration,
+		runs,
+		lastRunDuration,
+		lastRunFinished,
+		platformRequests,
+		platformRequestDuration,
+		platformRateLimitRemaining,
+	)
+}
+
+// HandleEvent records metrics from a run's events.
+func HandleEvent(e schema.Event) {
+	switch e.Type {
+	case schema.RepoDiscovered:
+		reposDiscovered.WithLabelValues(e.Platform).Inc()
+
+	case schema.RepoSkipped:
+		reposSkipped.WithLabelValues(e.Platform).Inc()
+
+	case schema.RepoFailed:
+		reposFailed.WithLabelValues(e.Platform).Inc()
+
+	case schema.JobRetried:
+		jobRetries.WithLabelValues(e.ChoreName, e.Reason).Inc()
+
+	case schema.JobSucceeded, schema.JobFailed, schema.JobTimedOut:
+		outcome := string(e.Type)
+		jobs.WithLabelValues(e.ChoreName, outcome).Inc()
+
+		// jobs that fail before reaching the executor don't have a duration
+		if e.Job != nil {
+			jobDuration.WithLabelValues(e.ChoreName, outcome).Observe(e.Job.Duration().Seconds())
+		}
+	}
+}
+
+// RecordRun records the outcome of a whole run.
+func RecordRun(duration time.Duration, err error) {
+	outcome := "succeeded"
+	if err != nil {
+		outcome = "failed"
+	}
+
+	runs.WithLabelValues(outcome).Inc()
+	lastRunDuration.Set(duration.Seconds())
+	lastRunFinished.SetToCurrentTime()
+}
+
+// RecordPlatformRequest records a single request to a platform API. A status code of zero indicates that no response was received.
+func RecordPlatformRequest(platform string, method string, statusCode int, duration time.Duration, header http.Header) {
+	statusLabel := "error"
+	if statusCode != 0 {
+		statusLabel = strconv.Itoa(statusCode)
+	}
+
+	platformRequests.WithLabelValues(platform, method, statusLabel).Inc()
+	platformRequestDuration.WithLabelValues(platform, method).Observe(duration.Seconds())
+
+	if header != nil {
+		if remaining, err := strconv.ParseFloat(header.Get("X-RateLimit-Remaining"), 64); err == nil {
+			platformRateLimitRemaining.WithLabelValues(platform).Set(remaining)
+		}
+	}
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// Push sends the metrics to a Pushgateway-compatible endpoint, replacing any previously pushed by Tedium.
+func Push(url string) error {
+	err := push.New(url, "tedium").Gatherer(Registry).Push()
+	if err != nil {
+		return fmt.Errorf("error pushing metrics: %w", err)
+	}
+
+	return nil
+}
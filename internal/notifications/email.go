@@ -0,0 +1,76 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/markormesher/tedium/internal/schema"
+)
+
+func sendEmail(ctx context.Context, notifier schema.NotifierConfig, summary RunSummary) error {
+	smtpConf := notifier.SMTP
+
+	bodyTemplate := notifier.Template
+	if bodyTemplate == "" {
+		bodyTemplate = defaultEmailTemplate
+	}
+
+	body, err := render(bodyTemplate, summary)
+	if err != nil {
+		return err
+	}
+
+	subjectTemplate := smtpConf.Subject
+	if subjectTemplate == "" {
+		subjectTemplate = defaultEmailSubject
+	}
+
+	subject, err := render(subjectTemplate, summary)
+	if err != nil {
+		return err
+	}
+
+	var message strings.Builder
+	message.WriteString("From: " + smtpConf.From + "\r\n")
+	message.WriteString("To: " + strings.Join(smtpConf.To, ", ") + "\r\n")
+	message.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", strings.TrimSpace(string(subject))) + "\r\n")
+	message.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	message.WriteString("MIME-Version: 1.0\r\n")
+	message.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	message.WriteString("\r\n")
+	message.WriteString(strings.ReplaceAll(string(body), "\n", "\r\n"))
+
+	var auth smtp.Auth
+	if smtpConf.Username != "" {
+		password, err := smtpConf.ReadPassword()
+		if err != nil {
+			return err
+		}
+
+		auth = smtp.PlainAuth("", smtpConf.Username, password, smtpConf.Host)
+	}
+
+	// smtp.SendMail doesn't accept a context, so run it in the background and give up waiting if the context ends first
+	address := net.JoinHostPort(smtpConf.Host, strconv.Itoa(smtpConf.Port))
+	result := make(chan error, 1)
+	go func() {
+		result <- smtp.SendMail(address, auth, smtpConf.From, smtpConf.To, []byte(message.String()))
+	}()
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("error sending email: %w", ctx.Err())
+
+	case err := <-result:
+		if err != nil {
+			return fmt.Errorf("error sending email: %w", err)
+		}
+		return nil
+	}
+}
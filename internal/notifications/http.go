@@ -0,0 +1,38 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/markormesher/tedium/internal/schema"
+)
+
+func postJSON(ctx context.Context, notifier schema.NotifierConfig, body []byte) error {
+	url, err := notifier.ReadURL()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	response, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending request: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		// the URL is deliberately left out because it may contain a secret
+		responseBody, _ := io.ReadAll(io.LimitReader(response.Body, 500))
+		return fmt.Errorf("notification endpoint returned status %s: %s", response.Status, responseBody)
+	}
+
+	return nil
+}
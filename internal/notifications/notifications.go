@@ -0,0 +1,233 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"text/template"
+	"time"
+
+	"github.com/markormesher/tedium/internal/schema"
+)
+
+// RunSummary is what notifications describe. It is passed to notification templates.
+type RunSummary struct {
+	RunID      string        `json:"runID"`
+	StartedAt  time.Time     `json:"startedAt"`
+	FinishedAt time.Time     `json:"finishedAt"`
+	Duration   time.Duration `json:"-"`
+
+	// Error is set if the run as a whole failed.
+	Error string `json:"error,omitempty"`
+
+	ReposDiscovered int `json:"reposDiscovered"`
+	ReposSkipped    int `json:"reposSkipped"`
+	JobsSucceeded   int `json:"jobsSucceeded"`
+
+	NewPullRequests     []schema.JobResult `json:"newPullRequests"`
+	UpdatedPullRequests []schema.JobResult `json:"updatedPullRequests"`
+	FailedRepos         []RepoFailure      `json:"failedRepos"`
+	FailedJobs          []schema.JobResult `json:"failedJobs"`
+}
+
+// RepoFailure describes a repo that couldn't be checked or whose config couldn't be resolved.
+type RepoFailure struct {
+	Platform string `json:"platform"`
+	Repo     string `json:"repo"`
+	Reason   string `json:"reason"`
+	Error    string `json:"error"`
+}
+
+// HasChanges reports whether the run opened or updated any PRs.
+func (s RunSummary) HasChanges() bool {
+	return len(s.NewPullRequests) > 0 || len(s.UpdatedPullRequests) > 0
+}
+
+// HasFailures reports whether anything in the run failed.
+func (s RunSummary) HasFailures() bool {
+	return s.Error != "" || len(s.FailedRepos) > 0 || len(s.FailedJobs) > 0
+}
+
+// notifyTimeout limits how long a single notification may take to send.
+const notifyTimeout = 30 * time.Second
+
+// Send delivers the summary to every notifier whose condition is met. Failures are logged rather than returned, because they don't affect the outcome of the run.
+func Send(ctx context.Context, notifiers []schema.NotifierConfig, summary RunSummary) {
+	for _, notifier := range notifiers {
+		if !shouldNotify(notifier, summary) {
+			continue
+		}
+
+		err := send(ctx, notifier, summary)
+		if err != nil {
+			slog.Error("error sending notification", "type", notifier.Type, "error", err)
+		} else {
+			slog.Info("sent notification", "type", notifier.Type)
+		}
+	}
+}
+
+func shouldNotify(notifier schema.NotifierConfig, summary RunSummary) bool {
+	switch notifier.When {
+	case schema.NotifyOnFailures:
+		return summary.HasFailures()
+
+	case schema.NotifyOnChanges:
+		return summary.HasChanges() || summary.HasFailures()
+
+	default:
+		return true
+	}
+}
+
+func send(ctx context.Context, notifier schema.NotifierConfig, summary RunSummary) error {
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+
+	switch notifier.Type {
+	case schema.NotifierTypeWebhook:
+		return sendWebhook(ctx, notifier, summary)
+
+	case schema.NotifierTypeSlack:
+		return sendSlack(ctx, notifier, summary)
+
+	case schema.NotifierTypeEmail:
+		return sendEmail(ctx, notifier, summary)
+
+	default:
+		// config is validated when it is loaded, so this shouldn't ever happen
+		return fmt.Errorf("unknown notifier type %s", notifier.Type)
+	}
+}
+
+func sendWebhook(ctx context.Context, notifier schema.NotifierConfig, summary RunSummary) error {
+	var body []byte
+	var err error
+
+	if notifier.Template == "" {
+		body, err = json.Marshal(summary)
+		if err != nil {
+			return fmt.Errorf("error encoding summary: %w", err)
+		}
+	} else {
+		body, err = render(notifier.Template, summary)
+		if err != nil {
+			return err
+		}
+	}
+
+	return postJSON(ctx, notifier, body)
+}
+
+func sendSlack(ctx context.Context, notifier schema.NotifierConfig, summary RunSummary) error {
+	tmpl := notifier.Template
+	if tmpl == "" {
+		tmpl = defaultSlackTemplate
+	}
+
+	text, err := render(tmpl, summary)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(map[string]string{"text": string(text)})
+	if err != nil {
+		return fmt.Errorf("error encoding Slack message: %w", err)
+	}
+
+	return postJSON(ctx, notifier, body)
+}
+
+// render executes a notification template with the run summary.
+func render(tmpl string, summary RunSummary) ([]byte, error) {
+	t, err := template.New("notification").Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing notification template: %w", err)
+	}
+
+	var output bytes.Buffer
+	err = t.Execute(&output, summary)
+	if err != nil {
+		return nil, fmt.Errorf("error executing notification template: %w", err)
+	}
+
+	return output.Bytes(), nil
+}
+
+const defaultSlackTemplate = `*Tedium run {{.RunID}}* finished in {{.Duration}}{{if .Error}} with an error: {{.Error}}{{end}}
+{{- if .NewPullRequests}}
+
+*New PRs*
+{{- range .NewPullRequests}}
+• {{.Repo}}: <{{.PullRequestURL}}|{{.ChoreName}}>
+{{- end}}
+{{- end}}
+{{- if .UpdatedPullRequests}}
+
+*Updated PRs*
+{{- range .UpdatedPullRequests}}
+• {{.Repo}}: <{{.PullRequestURL}}|{{.ChoreName}}>
+{{- end}}
+{{- end}}
+{{- if .FailedRepos}}
+
+*Failed repos*
+{{- range .FailedRepos}}
+• {{.Repo}}: {{.Reason}}
+{{- end}}
+{{- end}}
+{{- if .FailedJobs}}
+
+*Failed jobs*
+{{- range .FailedJobs}}
+• {{.Repo}}: {{.ChoreName}} ({{.Status}}{{if .FailedStep}} at {{.FailedStep}}{{end}})
+{{- end}}
+{{- end}}
+{{- if not (or .HasChanges .HasFailures)}}
+
+Nothing changed.
+{{- end}}
+`
+
+const defaultEmailTemplate = `Tedium run {{.RunID}} finished in {{.Duration}}.
+{{- if .Error}}
+
+The run failed: {{.Error}}
+{{- end}}
+{{- if .NewPullRequests}}
+
+New PRs:
+{{- range .NewPullRequests}}
+- {{.Repo}}: {{.ChoreName}} - {{.PullRequestURL}}
+{{- end}}
+{{- end}}
+{{- if .UpdatedPullRequests}}
+
+Updated PRs:
+{{- range .UpdatedPullRequests}}
+- {{.Repo}}: {{.ChoreName}} - {{.PullRequestURL}}
+{{- end}}
+{{- end}}
+{{- if .FailedRepos}}
+
+Failed repos:
+{{- range .FailedRepos}}
+- {{.Repo}}: {{.Reason}}: {{.Error}}
+{{- end}}
+{{- end}}
+{{- if .FailedJobs}}
+
+Failed jobs:
+{{- range .FailedJobs}}
+- {{.Repo}}: {{.ChoreName}} ({{.Status}}{{if .FailedStep}} at {{.FailedStep}}{{end}}): {{.Error}}
+{{- end}}
+{{- end}}
+{{- if not (or .HasChanges .HasFailures)}}
+
+Nothing changed.
+{{- end}}
+`
+
+const defaultEmailSubject = `Tedium run {{.RunID}}: {{len .NewPullRequests}} new PR(s), {{len .UpdatedPullRequests}} updated, {{len .FailedJobs}} failed job(s)`
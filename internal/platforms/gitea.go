@@ -22,6 +22,7 @@ type GiteaPlatform struct {
 	// generated locally
 	apiBaseURL *urllib.URL
 	profile    schema.PlatformProfile
+	client     *resty.Client
 }
 
 func giteaPlatformFromConfig(platformConfig schema.PlatformConfig) (*GiteaPlatform, error) {
@@ -32,6 +33,7 @@ func giteaPlatformFromConfig(platformConfig schema.PlatformConfig) (*GiteaPlatfo
 	p := GiteaPlatform{
 		PlatformConfig: platformConfig,
 		auth:           platformConfig.Auth,
+		client:         newHTTPClient(platformConfig.BaseURL),
 	}
 
 	// normalise primary base URL
@@ -116,6 +118,15 @@ func (p *GiteaPlatform) AuthToken() string {
 	return p.auth.TokenString
 }
 
+func (p *GiteaPlatform) JobAuth() (*schema.AuthConfig, error) {
+	if p.auth == nil {
+		return nil, nil
+	}
+
+	auth := *p.auth
+	return &auth, nil
+}
+
 func (p *GiteaPlatform) DiscoverRepos() ([]schema.Repo, error) {
 	if p.auth == nil {
 		slog.Warn("no auth configured for platform; skipping repo discovery", "baseURL", p.baseURLs[0])
@@ -148,7 +159,7 @@ func (p *GiteaPlatform) DiscoverRepos() ([]schema.Repo, error) {
 		}
 
 		if response.IsError() {
-			return nil, fmt.Errorf("error making Gitea API request, status: %v", response.Status())
+			return nil, fmt.Errorf("error making Gitea API request: %w", newAPIError(response))
 		}
 
 		for _, repo := range repoData.Data {
@@ -165,7 +176,6 @@ func (p *GiteaPlatform) DiscoverRepos() ([]schema.Repo, error) {
 				Auth: schema.RepoAuth{
 					// TODO: don't forget to set this properly when app auth is supported
 					Username: "x-access-token",
-					Password: p.auth.TokenString,
 				},
 				DefaultBranch: repo.DefaultBranch,
 				Archived:      repo.Archived,
@@ -218,7 +228,9 @@ func (p *GiteaPlatform) ReadRepoFile(repo schema.Repo, branch string, pathCandid
 			continue
 		}
 
-		// TODO: handle non-200 statuses
+		if !response.IsSuccess() {
+			return nil, fmt.Errorf("failed to read file via Gitea API: %w", newAPIError(response))
+		}
 
 		fileBytes, err := base64.StdEncoding.DecodeString(repoFile.Content)
 		if err != nil {
@@ -232,11 +244,9 @@ func (p *GiteaPlatform) ReadRepoFile(repo schema.Repo, branch string, pathCandid
 	return nil, nil
 }
 
-func (p *GiteaPlatform) OpenOrUpdatePullRequest(job schema.Job) error {
-	slog.Info("opening or updating PR", "chore", job.Chore.Name)
-
+func (p *GiteaPlatform) FindPullRequest(job schema.Job) (*schema.PullRequest, error) {
 	var existingPrs []struct {
-		Num   int    `json:"number"`
+		schema.PullRequest
 		State string `json:"state"`
 
 		Base struct {
@@ -252,21 +262,35 @@ func (p *GiteaPlatform) OpenOrUpdatePullRequest(job schema.Job) error {
 	req.SetResult(&existingPrs)
 	response, err := req.Get(fmt.Sprintf("%s/repos/%s/%s/pulls", p.apiBaseURL, job.Repo.OwnerName, job.Repo.Name))
 	if err != nil {
-		return fmt.Errorf("error fetching existing PRs: %w", err)
+		return nil, fmt.Errorf("error fetching existing PRs: %w", err)
 	}
 
 	if !response.IsSuccess() {
-		return fmt.Errorf("error fetching existing PRs: %v", string(response.Body()))
+		return nil, fmt.Errorf("error fetching existing PRs: %w", newAPIError(response))
 	}
 
-	var existingPrNum int
 	for _, pr := range existingPrs {
 		if pr.Base.Label == job.Repo.DefaultBranch && pr.Head.Label == job.FinalBranchName && pr.State == "open" {
-			existingPrNum = pr.Num
-			break
+			return &pr.PullRequest, nil
 		}
 	}
 
+	return nil, nil
+}
+
+func (p *GiteaPlatform) OpenOrUpdatePullRequest(job schema.Job) (schema.PullRequest, error) {
+	slog.Info("opening or updating PR", "chore", job.Chore.Name)
+
+	existingPr, err := p.FindPullRequest(job)
+	if err != nil {
+		return schema.PullRequest{}, err
+	}
+
+	var existingPrNum int
+	if existingPr != nil {
+		existingPrNum = existingPr.Number
+	}
+
 	prBody := map[string]any{
 		"base":  job.Repo.DefaultBranch,
 		"head":  job.FinalBranchName,
@@ -274,9 +298,12 @@ func (p *GiteaPlatform) OpenOrUpdatePullRequest(job schema.Job) error {
 		"body":  job.Chore.PrBody(),
 	}
 
-	_, req = p.authedRequest()
+	_, req := p.authedRequest()
+	var pr schema.PullRequest
+	var response *resty.Response
 	req.SetHeader("Content-type", "application/json")
 	req.SetBody(prBody)
+	req.SetResult(&pr)
 
 	if existingPrNum == 0 {
 		slog.Debug("opening PR")
@@ -287,11 +314,198 @@ func (p *GiteaPlatform) OpenOrUpdatePullRequest(job schema.Job) error {
 	}
 
 	if err != nil {
-		return fmt.Errorf("error opening or updating PR: %w", err)
+		return schema.PullRequest{}, fmt.Errorf("error opening or updating PR: %w", err)
+	}
+
+	if !response.IsSuccess() {
+		return schema.PullRequest{}, fmt.Errorf("error opening or updating PR: %w", newAPIError(response))
+	}
+
+	pr.Created = existingPrNum == 0
+	return pr, nil
+}
+
+func (p *GiteaPlatform) FindIssue(repo schema.Repo, title string) (*schema.Issue, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/issues?state=open&type=issues&page=1&limit=50", p.apiBaseURL, repo.OwnerName, repo.Name)
+
+	for {
+		var issues []schema.Issue
+
+		_, req := p.authedRequest()
+		req.SetResult(&issues)
+
+		response, err := req.Get(url)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching existing issues: %w", err)
+		}
+
+		if !response.IsSuccess() {
+			return nil, fmt.Errorf("error fetching existing issues: %w", newAPIError(response))
+		}
+
+		for _, issue := range issues {
+			if issue.Title == title {
+				return &issue, nil
+			}
+		}
+
+		linkHeaders := utils.ParseLinkHeader(response.Header().Get("link"))
+		if nextLink, ok := linkHeaders["next"]; ok {
+			url = nextLink
+		} else {
+			break
+		}
+	}
+
+	return nil, nil
+}
+
+func (p *GiteaPlatform) FindIssuesByTitle(title string) (map[string]schema.Issue, error) {
+	output := map[string]schema.Issue{}
+
+	// the search endpoint isn't scoped to a repo, and "created" limits it to issues opened by the authenticated user (i.e. Tedium)
+	for page := 1; ; page++ {
+		var issues []struct {
+			schema.Issue
+
+			Repository struct {
+				FullName string `json:"full_name"`
+			} `json:"repository"`
+		}
+
+		_, req := p.authedRequest()
+		req.SetQueryParams(map[string]string{
+			"state":   "open",
+			"type":    "issues",
+			"created": "true",
+			"q":       title,
+			"page":    fmt.Sprintf("%d", page),
+			"limit":   "50",
+		})
+		req.SetResult(&issues)
+
+		response, err := req.Get(fmt.Sprintf("%s/repos/issues/search", p.apiBaseURL))
+		if err != nil {
+			return nil, fmt.Errorf("error searching for issues: %w", err)
+		}
+
+		if !response.IsSuccess() {
+			return nil, fmt.Errorf("error searching for issues: %w", newAPIError(response))
+		}
+
+		for _, issue := range issues {
+			if issue.Title == title {
+				output[issue.Repository.FullName] = issue.Issue
+			}
+		}
+
+		if len(issues) < 50 {
+			break
+		}
+	}
+
+	return output, nil
+}
+
+func (p *GiteaPlatform) OpenOrUpdateIssue(repo schema.Repo, existing *schema.Issue, title string, body string) (schema.Issue, error) {
+	if existing != nil && existing.Body == body {
+		return *existing, nil
+	}
+
+	_, req := p.authedRequest()
+
+	var issue schema.Issue
+	req.SetHeader("Content-type", "application/json")
+	req.SetBody(map[string]any{
+		"title": title,
+		"body":  body,
+	})
+	req.SetResult(&issue)
+
+	var response *resty.Response
+	var err error
+	if existing == nil {
+		slog.Info("opening issue", "repo", repo.FullName(), "title", title)
+		response, err = req.Post(fmt.Sprintf("%s/repos/%s/%s/issues", p.apiBaseURL, repo.OwnerName, repo.Name))
+	} else {
+		slog.Info("updating issue", "repo", repo.FullName(), "title", title)
+		response, err = req.Patch(fmt.Sprintf("%s/repos/%s/%s/issues/%d", p.apiBaseURL, repo.OwnerName, repo.Name, existing.Number))
+	}
+
+	if err != nil {
+		return schema.Issue{}, fmt.Errorf("error opening or updating issue: %w", err)
+	}
+
+	if !response.IsSuccess() {
+		return schema.Issue{}, fmt.Errorf("error opening or updating issue: %w", newAPIError(response))
+	}
+
+	return issue, nil
+}
+
+func (p *GiteaPlatform) CloseIssue(repo schema.Repo, number int) error {
+	slog.Info("closing issue", "repo", repo.FullName(), "number", number)
+	_, req := p.authedRequest()
+	req.SetHeader("Content-type", "application/json")
+	req.SetBody(map[string]any{
+		"state": "closed",
+	})
+
+	response, err := req.Patch(fmt.Sprintf("%s/repos/%s/%s/issues/%d", p.apiBaseURL, repo.OwnerName, repo.Name, number))
+	if err != nil {
+		return fmt.Errorf("error closing issue: %w", err)
+	}
+
+	if !response.IsSuccess() {
+		return fmt.Errorf("error closing issue: %w", newAPIError(response))
+	}
+
+	return nil
+}
+
+func (p *GiteaPlatform) SetCommitStatus(repo schema.Repo, branch string, status schema.CommitStatus) error {
+	var branchInfo struct {
+		Commit struct {
+			ID string `json:"id"`
+		} `json:"commit"`
+	}
+
+	_, req := p.authedRequest()
+	req.SetResult(&branchInfo)
+	response, err := req.Get(fmt.Sprintf("%s/repos/%s/%s/branches/%s", p.apiBaseURL, repo.OwnerName, repo.Name, branch))
+	if err != nil {
+		return fmt.Errorf("error fetching branch: %w", err)
+	}
+
+	if response.StatusCode() == 404 {
+		return nil
+	}
+
+	if !response.IsSuccess() {
+		return fmt.Errorf("error fetching branch: %w", newAPIError(response))
+	}
+
+	slog.Debug("setting commit status", "repo", repo.FullName(), "sha", branchInfo.Commit.ID, "context", status.Context, "state", status.State)
+	_, req = p.authedRequest()
+	req.SetHeader("Content-type", "application/json")
+	body := map[string]any{
+		"state":       status.State,
+		"context":     status.Context,
+		"description": status.Description,
+	}
+	if status.TargetURL != "" {
+		body["target_url"] = status.TargetURL
+	}
+
+	req.SetBody(body)
+
+	response, err = req.Post(fmt.Sprintf("%s/repos/%s/%s/statuses/%s", p.apiBaseURL, repo.OwnerName, repo.Name, branchInfo.Commit.ID))
+	if err != nil {
+		return fmt.Errorf("error setting commit status: %w", err)
 	}
 
 	if !response.IsSuccess() {
-		return fmt.Errorf("error opening or updating PR: %v", string(response.Body()))
+		return fmt.Errorf("error setting commit status: %w", newAPIError(response))
 	}
 
 	return nil
@@ -317,7 +531,7 @@ func (p *GiteaPlatform) loadProfile() error {
 	}
 
 	if response.IsError() {
-		return fmt.Errorf("failed to load user profile, status: %v", response.Status())
+		return fmt.Errorf("failed to load user profile: %w", newAPIError(response))
 	}
 
 	p.profile = schema.PlatformProfile{
@@ -328,8 +542,8 @@ func (p *GiteaPlatform) loadProfile() error {
 }
 
 func (p *GiteaPlatform) authedRequest() (*resty.Client, *resty.Request) {
-	client := resty.New()
-	request := client.NewRequest()
+	client := p.client
+	request := client.R()
 
 	if p.auth == nil {
 		return client, request
@@ -6,12 +6,18 @@ import (
 	"log/slog"
 	urllib "net/url"
 	"os"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/markormesher/tedium/internal/schema"
 	"github.com/markormesher/tedium/internal/utils"
 )
 
+// installationTokenRefreshMargin is how long before an installation token expires that we replace it, so that it doesn't expire part-way through a run of requests.
+const installationTokenRefreshMargin = 5 * time.Minute
+
 type GitHubPlatform struct {
 	schema.PlatformConfig
 
@@ -22,12 +28,20 @@ type GitHubPlatform struct {
 	// generated locally
 	apiBaseURL *urllib.URL
 	profile    schema.PlatformProfile
+	client     *resty.Client
+
+	// authLock guards the lazily-generated installation token, as requests may be made from several goroutines
+	authLock sync.Mutex
+
+	// searchAuthor identifies Tedium in search queries, so that searches only return issues it opened
+	searchAuthor string
 }
 
 func githubPlatformFromConfig(platformConfig schema.PlatformConfig) (*GitHubPlatform, error) {
 	p := GitHubPlatform{
 		PlatformConfig: platformConfig,
 		auth:           platformConfig.Auth,
+		client:         newHTTPClient(platformConfig.BaseURL),
 	}
 
 	// normalise primary base URL
@@ -116,13 +130,38 @@ func (p *GitHubPlatform) AuthToken() string {
 		return p.auth.TokenString
 
 	case schema.AuthConfigTypeApp:
-		return p.auth.AppInstallationToken
+		token, err := p.installationToken()
+		if err != nil {
+			slog.Warn("error generating installation access token", "error", err)
+			return ""
+		}
+
+		return token
 
 	default:
 		return ""
 	}
 }
 
+func (p *GitHubPlatform) JobAuth() (*schema.AuthConfig, error) {
+	if p.auth == nil {
+		return nil, nil
+	}
+
+	if p.auth.Type == schema.AuthConfigTypeApp {
+		_, err := p.installationToken()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	p.authLock.Lock()
+	defer p.authLock.Unlock()
+
+	auth := *p.auth
+	return &auth, nil
+}
+
 func (p *GitHubPlatform) DiscoverRepos() ([]schema.Repo, error) {
 	if p.auth == nil {
 		slog.Warn("no auth configured for paltform; skipping repo discovery", "baseURL", p.baseURLs)
@@ -158,7 +197,7 @@ func (p *GitHubPlatform) DiscoverRepos() ([]schema.Repo, error) {
 			}
 
 			if response.IsError() {
-				return nil, fmt.Errorf("error making GitHub API request, status: %v", response.Status())
+				return nil, fmt.Errorf("error making GitHub API request: %w", newAPIError(response))
 			}
 
 			for _, repo := range repoData {
@@ -174,7 +213,6 @@ func (p *GitHubPlatform) DiscoverRepos() ([]schema.Repo, error) {
 					CloneURL: cloneURL,
 					Auth: schema.RepoAuth{
 						Username: "x-access-token",
-						Password: p.auth.TokenString,
 					},
 					DefaultBranch: repo.DefaultBranch,
 					Archived:      repo.Archived,
@@ -221,7 +259,7 @@ func (p *GitHubPlatform) DiscoverRepos() ([]schema.Repo, error) {
 			}
 
 			if response.IsError() {
-				return nil, fmt.Errorf("error making GitHub API request, status: %v", response.Status())
+				return nil, fmt.Errorf("error making GitHub API request: %w", newAPIError(response))
 			}
 
 			for _, repo := range repoData.Repos {
@@ -237,7 +275,6 @@ func (p *GitHubPlatform) DiscoverRepos() ([]schema.Repo, error) {
 					CloneURL: cloneURL,
 					Auth: schema.RepoAuth{
 						Username: "x-access-token",
-						Password: p.auth.AppInstallationToken,
 					},
 					DefaultBranch: repo.DefaultBranch,
 					Archived:      repo.Archived,
@@ -296,7 +333,9 @@ func (p *GitHubPlatform) ReadRepoFile(repo schema.Repo, branch string, pathCandi
 			continue
 		}
 
-		// TODO: handle non-200 statuses
+		if !response.IsSuccess() {
+			return nil, fmt.Errorf("failed to read file via GitHub API: %w", newAPIError(response))
+		}
 
 		fileBytes, err := base64.StdEncoding.DecodeString(repoFile.Content)
 		if err != nil {
@@ -310,11 +349,9 @@ func (p *GitHubPlatform) ReadRepoFile(repo schema.Repo, branch string, pathCandi
 	return nil, nil
 }
 
-func (p *GitHubPlatform) OpenOrUpdatePullRequest(job schema.Job) error {
-	slog.Info("opening or updating PR", "chore", job.Chore.Name)
-
+func (p *GitHubPlatform) FindPullRequest(job schema.Job) (*schema.PullRequest, error) {
 	var existingPrs []struct {
-		Num   int    `json:"number"`
+		schema.PullRequest
 		State string `json:"state"`
 
 		Base struct {
@@ -327,27 +364,41 @@ func (p *GitHubPlatform) OpenOrUpdatePullRequest(job schema.Job) error {
 
 	_, req, err := p.authedUserOrInstallationRequest()
 	if err != nil {
-		return fmt.Errorf("error fetching existing PRs: %w", err)
+		return nil, fmt.Errorf("error fetching existing PRs: %w", err)
 	}
 
 	req.SetResult(&existingPrs)
 	response, err := req.Get(fmt.Sprintf("%s/repos/%s/%s/pulls", p.apiBaseURL, job.Repo.OwnerName, job.Repo.Name))
 	if err != nil {
-		return fmt.Errorf("error fetching existing PRs: %w", err)
+		return nil, fmt.Errorf("error fetching existing PRs: %w", err)
 	}
 
 	if !response.IsSuccess() {
-		return fmt.Errorf("error fetching existing PRs: %v", string(response.Body()))
+		return nil, fmt.Errorf("error fetching existing PRs: %w", newAPIError(response))
 	}
 
-	var existingPrNum int
 	for _, pr := range existingPrs {
 		if pr.Base.Label == fmt.Sprintf("%s:%s", job.Repo.OwnerName, job.Repo.DefaultBranch) && pr.Head.Label == fmt.Sprintf("%s:%s", job.Repo.OwnerName, job.FinalBranchName) && pr.State == "open" {
-			existingPrNum = pr.Num
-			break
+			return &pr.PullRequest, nil
 		}
 	}
 
+	return nil, nil
+}
+
+func (p *GitHubPlatform) OpenOrUpdatePullRequest(job schema.Job) (schema.PullRequest, error) {
+	slog.Info("opening or updating PR", "chore", job.Chore.Name)
+
+	existingPr, err := p.FindPullRequest(job)
+	if err != nil {
+		return schema.PullRequest{}, err
+	}
+
+	var existingPrNum int
+	if existingPr != nil {
+		existingPrNum = existingPr.Number
+	}
+
 	prBody := map[string]any{
 		"base":  job.Repo.DefaultBranch,
 		"head":  fmt.Sprintf("%s:%s", job.Repo.OwnerName, job.FinalBranchName),
@@ -355,13 +406,16 @@ func (p *GitHubPlatform) OpenOrUpdatePullRequest(job schema.Job) error {
 		"body":  job.Chore.PrBody(),
 	}
 
-	_, req, err = p.authedUserOrInstallationRequest()
+	_, req, err := p.authedUserOrInstallationRequest()
 	if err != nil {
-		return fmt.Errorf("error fetching existing PRs: %w", err)
+		return schema.PullRequest{}, fmt.Errorf("error opening or updating PR: %w", err)
 	}
 
+	var pr schema.PullRequest
+	var response *resty.Response
 	req.SetHeader("Content-type", "application/json")
 	req.SetBody(prBody)
+	req.SetResult(&pr)
 
 	if existingPrNum == 0 {
 		slog.Debug("opening PR")
@@ -372,11 +426,258 @@ func (p *GitHubPlatform) OpenOrUpdatePullRequest(job schema.Job) error {
 	}
 
 	if err != nil {
-		return fmt.Errorf("error opening or updating PR: %w", err)
+		return schema.PullRequest{}, fmt.Errorf("error opening or updating PR: %w", err)
 	}
 
 	if !response.IsSuccess() {
-		return fmt.Errorf("error opening or updating PR: status %d", response.StatusCode())
+		return schema.PullRequest{}, fmt.Errorf("error opening or updating PR: %w", newAPIError(response))
+	}
+
+	pr.Created = existingPrNum == 0
+	return pr, nil
+}
+
+func (p *GitHubPlatform) FindIssue(repo schema.Repo, title string) (*schema.Issue, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/issues?state=open&page=1&per_page=100", p.apiBaseURL, repo.OwnerName, repo.Name)
+
+	for {
+		var issues []struct {
+			schema.Issue
+
+			// set for PRs, which GitHub also returns from the issues API
+			PullRequest *struct{} `json:"pull_request"`
+		}
+
+		_, req, err := p.authedUserOrInstallationRequest()
+		if err != nil {
+			return nil, fmt.Errorf("error fetching existing issues: %w", err)
+		}
+
+		req.SetResult(&issues)
+		response, err := req.Get(url)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching existing issues: %w", err)
+		}
+
+		if !response.IsSuccess() {
+			return nil, fmt.Errorf("error fetching existing issues: %w", newAPIError(response))
+		}
+
+		for _, issue := range issues {
+			if issue.PullRequest == nil && issue.Title == title {
+				return &issue.Issue, nil
+			}
+		}
+
+		linkHeaders := utils.ParseLinkHeader(response.Header().Get("link"))
+		if nextLink, ok := linkHeaders["next"]; ok {
+			url = nextLink
+		} else {
+			break
+		}
+	}
+
+	return nil, nil
+}
+
+func (p *GitHubPlatform) FindIssuesByTitle(title string) (map[string]schema.Issue, error) {
+	output := map[string]schema.Issue{}
+
+	if p.searchAuthor == "" {
+		// without auth Tedium can't have opened any issues
+		return output, nil
+	}
+
+	query := fmt.Sprintf("is:issue is:open in:title %q author:%s", title, p.searchAuthor)
+	url := fmt.Sprintf("%s/search/issues?q=%s&page=1&per_page=100", p.apiBaseURL, urllib.QueryEscape(query))
+
+	for {
+		var results struct {
+			Items []struct {
+				schema.Issue
+
+				// e.g. https://api.github.com/repos/owner/name
+				RepositoryURL string `json:"repository_url"`
+			} `json:"items"`
+		}
+
+		_, req, err := p.authedUserOrInstallationRequest()
+		if err != nil {
+			return nil, fmt.Errorf("error searching for issues: %w", err)
+		}
+
+		req.SetResult(&results)
+		response, err := req.Get(url)
+		if err != nil {
+			return nil, fmt.Errorf("error searching for issues: %w", err)
+		}
+
+		if !response.IsSuccess() {
+			return nil, fmt.Errorf("error searching for issues: %w", newAPIError(response))
+		}
+
+		for _, issue := range results.Items {
+			if issue.Title != title {
+				// the search matches titles that contain the query, not just identical ones
+				continue
+			}
+
+			segments := strings.Split(strings.TrimSuffix(issue.RepositoryURL, "/"), "/")
+			if len(segments) < 2 {
+				continue
+			}
+
+			output[segments[len(segments)-2]+"/"+segments[len(segments)-1]] = issue.Issue
+		}
+
+		linkHeaders := utils.ParseLinkHeader(response.Header().Get("link"))
+		if nextLink, ok := linkHeaders["next"]; ok {
+			url = nextLink
+		} else {
+			break
+		}
+	}
+
+	return output, nil
+}
+
+func (p *GitHubPlatform) OpenOrUpdateIssue(repo schema.Repo, existing *schema.Issue, title string, body string) (schema.Issue, error) {
+	if existing != nil && existing.Body == body {
+		return *existing, nil
+	}
+
+	_, req, err := p.authedUserOrInstallationRequest()
+	if err != nil {
+		return schema.Issue{}, fmt.Errorf("error opening or updating issue: %w", err)
+	}
+
+	var issue schema.Issue
+	req.SetHeader("Content-type", "application/json")
+	req.SetBody(map[string]any{
+		"title": title,
+		"body":  body,
+	})
+	req.SetResult(&issue)
+
+	var response *resty.Response
+	if existing == nil {
+		slog.Info("opening issue", "repo", repo.FullName(), "title", title)
+		response, err = req.Post(fmt.Sprintf("%s/repos/%s/%s/issues", p.apiBaseURL, repo.OwnerName, repo.Name))
+	} else {
+		slog.Info("updating issue", "repo", repo.FullName(), "title", title)
+		response, err = req.Patch(fmt.Sprintf("%s/repos/%s/%s/issues/%d", p.apiBaseURL, repo.OwnerName, repo.Name, existing.Number))
+	}
+
+	if err != nil {
+		return schema.Issue{}, fmt.Errorf("error opening or updating issue: %w", err)
+	}
+
+	if !response.IsSuccess() {
+		return schema.Issue{}, fmt.Errorf("error opening or updating issue: %w", newAPIError(response))
+	}
+
+	return issue, nil
+}
+
+func (p *GitHubPlatform) CloseIssue(repo schema.Repo, number int) error {
+	_, req, err := p.authedUserOrInstallationRequest()
+	if err != nil {
+		return fmt.Errorf("error closing issue: %w", err)
+	}
+
+	slog.Info("closing issue", "repo", repo.FullName(), "number", number)
+	req.SetHeader("Content-type", "application/json")
+	req.SetBody(map[string]any{
+		"state": "closed",
+	})
+
+	response, err := req.Patch(fmt.Sprintf("%s/repos/%s/%s/issues/%d", p.apiBaseURL, repo.OwnerName, repo.Name, number))
+	if err != nil {
+		return fmt.Errorf("error closing issue: %w", err)
+	}
+
+	if !response.IsSuccess() {
+		return fmt.Errorf("error closing issue: %w", newAPIError(response))
+	}
+
+	return nil
+}
+
+func (p *GitHubPlatform) SetCommitStatus(repo schema.Repo, branch string, status schema.CommitStatus) error {
+	var branchInfo struct {
+		Commit struct {
+			SHA string `json:"sha"`
+		} `json:"commit"`
+	}
+
+	_, req, err := p.authedUserOrInstallationRequest()
+	if err != nil {
+		return fmt.Errorf("error fetching branch: %w", err)
+	}
+
+	req.SetResult(&branchInfo)
+	response, err := req.Get(fmt.Sprintf("%s/repos/%s/%s/branches/%s", p.apiBaseURL, repo.OwnerName, repo.Name, branch))
+	if err != nil {
+		return fmt.Errorf("error fetching branch: %w", err)
+	}
+
+	if response.StatusCode() == 404 {
+		return nil
+	}
+
+	if !response.IsSuccess() {
+		return fmt.Errorf("error fetching branch: %w", newAPIError(response))
+	}
+
+	sha := branchInfo.Commit.SHA
+
+	_, req, err = p.authedUserOrInstallationRequest()
+	if err != nil {
+		return fmt.Errorf("error setting commit status: %w", err)
+	}
+
+	req.SetHeader("Content-type", "application/json")
+
+	// check runs can only be created by apps, so users get a plain commit status instead
+	if p.auth != nil && p.auth.Type == schema.AuthConfigTypeApp {
+		slog.Debug("creating check run", "repo", repo.FullName(), "sha", sha, "name", status.Context, "conclusion", status.State)
+		body := map[string]any{
+			"name":       status.Context,
+			"head_sha":   sha,
+			"status":     "completed",
+			"conclusion": status.State,
+			"output": map[string]any{
+				"title":   status.Description,
+				"summary": status.Description,
+			},
+		}
+		if status.TargetURL != "" {
+			body["details_url"] = status.TargetURL
+		}
+
+		req.SetBody(body)
+		response, err = req.Post(fmt.Sprintf("%s/repos/%s/%s/check-runs", p.apiBaseURL, repo.OwnerName, repo.Name))
+	} else {
+		slog.Debug("setting commit status", "repo", repo.FullName(), "sha", sha, "context", status.Context, "state", status.State)
+		body := map[string]any{
+			"state":       status.State,
+			"context":     status.Context,
+			"description": status.Description,
+		}
+		if status.TargetURL != "" {
+			body["target_url"] = status.TargetURL
+		}
+
+		req.SetBody(body)
+		response, err = req.Post(fmt.Sprintf("%s/repos/%s/%s/statuses/%s", p.apiBaseURL, repo.OwnerName, repo.Name, sha))
+	}
+
+	if err != nil {
+		return fmt.Errorf("error setting commit status: %w", err)
+	}
+
+	if !response.IsSuccess() {
+		return fmt.Errorf("error setting commit status: %w", newAPIError(response))
 	}
 
 	return nil
@@ -408,7 +709,7 @@ func (p *GitHubPlatform) loadProfile() error {
 		}
 
 		if response.IsError() {
-			return fmt.Errorf("failed to load user profile: %v", response.Status())
+			return fmt.Errorf("failed to load user profile: %w", newAPIError(response))
 		}
 
 		primaryEmail := ""
@@ -426,6 +727,7 @@ func (p *GitHubPlatform) loadProfile() error {
 		p.profile = schema.PlatformProfile{
 			Email: primaryEmail,
 		}
+		p.searchAuthor = "@me"
 
 		return nil
 
@@ -446,12 +748,13 @@ func (p *GitHubPlatform) loadProfile() error {
 		}
 
 		if response.IsError() {
-			return fmt.Errorf("failed to load app profile: %v", response.Status())
+			return fmt.Errorf("failed to load app profile: %w", newAPIError(response))
 		}
 
 		p.profile = schema.PlatformProfile{
 			Email: appProfile.Slug + "[bot]@users.noreply.github.com",
 		}
+		p.searchAuthor = "app/" + appProfile.Slug
 
 		return nil
 
@@ -467,8 +770,8 @@ func (p *GitHubPlatform) loadProfile() error {
 
 func (p *GitHubPlatform) authedUserOrInstallationRequest() (*resty.Client, *resty.Request, error) {
 	if p.auth == nil {
-		client := resty.New()
-		request := client.NewRequest()
+		client := p.client
+		request := client.R()
 		return client, request, nil
 	}
 
@@ -485,8 +788,8 @@ func (p *GitHubPlatform) authedUserOrInstallationRequest() (*resty.Client, *rest
 }
 
 func (p *GitHubPlatform) authedUserRequest() (*resty.Client, *resty.Request, error) {
-	client := resty.New()
-	request := client.NewRequest()
+	client := p.client
+	request := client.R()
 
 	if p.auth == nil {
 		return nil, nil, fmt.Errorf("error making authed request to GitHub: no auth config found")
@@ -503,8 +806,8 @@ func (p *GitHubPlatform) authedUserRequest() (*resty.Client, *resty.Request, err
 }
 
 func (p *GitHubPlatform) authedAppRequest() (*resty.Client, *resty.Request, error) {
-	client := resty.New()
-	request := client.NewRequest()
+	client := p.client
+	request := client.R()
 
 	if p.auth == nil {
 		return nil, nil, fmt.Errorf("error making authed request to GitHub: no auth config found")
@@ -526,8 +829,8 @@ func (p *GitHubPlatform) authedAppRequest() (*resty.Client, *resty.Request, erro
 }
 
 func (p *GitHubPlatform) authedInstallationRequest() (*resty.Client, *resty.Request, error) {
-	client := resty.New()
-	request := client.NewRequest()
+	client := p.client
+	request := client.R()
 
 	if p.auth == nil {
 		return nil, nil, fmt.Errorf("error making authed request to GitHub: no auth config found")
@@ -537,32 +840,48 @@ func (p *GitHubPlatform) authedInstallationRequest() (*resty.Client, *resty.Requ
 		return nil, nil, fmt.Errorf("error making installation-authed request to GitHub: auth type is not %s", schema.AuthConfigTypeApp)
 	}
 
-	// generate new installation token if we don't have one already
-	if p.auth.AppInstallationToken == "" {
-		var installationToken struct {
-			Token string `json:"token"`
-		}
+	token, err := p.installationToken()
+	if err != nil {
+		return nil, nil, err
+	}
 
-		_, req, err := p.authedAppRequest()
-		if err != nil {
-			return nil, nil, err
-		}
-		req.SetResult(&installationToken)
-		response, err := req.Post(fmt.Sprintf("%s/app/installations/%s/access_tokens", p.apiBaseURL, p.auth.InstallationID))
+	request.SetHeader("Authorization", fmt.Sprintf("Bearer %s", token))
+	request.SetHeader("User-Agent", "Tedium")
 
-		if err != nil {
-			return nil, nil, fmt.Errorf("error generating installation access token: %w", err)
-		}
+	return client, request, nil
+}
 
-		if response.IsError() {
-			return nil, nil, fmt.Errorf("error generating installation access token, status: %v", response.Status())
-		}
+// installationToken returns the current installation token, generating a new one if we don't have one yet or it is about to expire.
+func (p *GitHubPlatform) installationToken() (string, error) {
+	p.authLock.Lock()
+	defer p.authLock.Unlock()
 
-		p.auth.AppInstallationToken = installationToken.Token
+	if p.auth.AppInstallationToken != "" && time.Now().Add(installationTokenRefreshMargin).Before(p.auth.AppInstallationTokenExpiry) {
+		return p.auth.AppInstallationToken, nil
 	}
 
-	request.SetHeader("Authorization", fmt.Sprintf("Bearer %s", p.auth.AppInstallationToken))
-	request.SetHeader("User-Agent", "Tedium")
+	var installationToken struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
 
-	return client, request, nil
+	_, req, err := p.authedAppRequest()
+	if err != nil {
+		return "", err
+	}
+	req.SetResult(&installationToken)
+	response, err := req.Post(fmt.Sprintf("%s/app/installations/%s/access_tokens", p.apiBaseURL, p.auth.InstallationID))
+
+	if err != nil {
+		return "", fmt.Errorf("error generating installation access token: %w", err)
+	}
+
+	if response.IsError() {
+		return "", fmt.Errorf("error generating installation access token: %w", newAPIError(response))
+	}
+
+	p.auth.AppInstallationToken = installationToken.Token
+	p.auth.AppInstallationTokenExpiry = installationToken.ExpiresAt
+
+	return p.auth.AppInstallationToken, nil
 }
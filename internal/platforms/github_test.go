@@ -0,0 +1,118 @@
+package platforms
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	urllib "net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/markormesher/tedium/internal/schema"
+)
+
+// An issue on a later page must not be mistaken for a PR just because a PR was in the same position on an earlier page.
+func TestGitHubFindIssueAcrossPages(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.URL.Query().Get("page") == "1" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/owner/repo/issues?state=open&page=2&per_page=100>; rel="next"`, server.URL))
+			_, _ = w.Write([]byte(`[{"number": 1, "title": "Tedium Dashboard", "body": "", "pull_request": {}}]`))
+			return
+		}
+
+		_, _ = w.Write([]byte(`[{"number": 2, "title": "Tedium Dashboard", "body": "dashboard"}]`))
+	}))
+	defer server.Close()
+
+	p, err := githubPlatformFromConfig(schema.PlatformConfig{
+		Type:    "github",
+		BaseURL: "https://github.example.com",
+		Auth:    &schema.AuthConfig{Type: schema.AuthConfigTypeUserToken, TokenString: "token"},
+	})
+	if err != nil {
+		t.Fatalf("error building platform: %v", err)
+	}
+
+	p.apiBaseURL, err = urllib.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("error parsing server URL: %v", err)
+	}
+
+	issue, err := p.FindIssue(schema.Repo{OwnerName: "owner", Name: "repo"}, "Tedium Dashboard")
+	if err != nil {
+		t.Fatalf("FindIssue() error = %v", err)
+	}
+
+	if issue == nil || issue.Number != 2 {
+		t.Errorf("FindIssue() = %+v, want issue #2", issue)
+	}
+}
+
+// Jobs that start more than an hour apart must each get an installation token that is still valid, not the one from when repos were discovered.
+func TestGitHubJobAuthRefreshesInstallationToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+
+	var issued atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/app/installations/42/access_tokens" {
+			http.NotFound(w, r)
+			return
+		}
+
+		// each token is already inside the refresh margin, so every job needs a new one
+		n := issued.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"token": "token-%d", "expires_at": %q}`, n, time.Now().Add(time.Minute).Format(time.RFC3339))
+	}))
+	defer server.Close()
+
+	p, err := githubPlatformFromConfig(schema.PlatformConfig{
+		Type:    "github",
+		BaseURL: "https://github.example.com",
+		Auth: &schema.AuthConfig{
+			Type:             schema.AuthConfigTypeApp,
+			ClientID:         "client",
+			InstallationID:   "42",
+			PrivateKeyString: string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})),
+		},
+	})
+	if err != nil {
+		t.Fatalf("error building platform: %v", err)
+	}
+
+	p.apiBaseURL, err = urllib.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("error parsing server URL: %v", err)
+	}
+
+	first, err := p.JobAuth()
+	if err != nil {
+		t.Fatalf("JobAuth() error = %v", err)
+	}
+
+	second, err := p.JobAuth()
+	if err != nil {
+		t.Fatalf("JobAuth() error = %v", err)
+	}
+
+	if first.Token() != "token-1" || second.Token() != "token-2" {
+		t.Errorf("JobAuth() gave tokens %q then %q, want token-1 then token-2", first.Token(), second.Token())
+	}
+
+	// each job gets its own copy, which is serialised while other goroutines may be refreshing the platform's token
+	first.AppInstallationToken = "changed"
+	if p.auth.AppInstallationToken != "token-2" {
+		t.Errorf("changing a job's auth changed the platform's token to %q", p.auth.AppInstallationToken)
+	}
+}
@@ -0,0 +1,149 @@
+package platforms
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/markormesher/tedium/internal/metrics"
+)
+
+const (
+	httpRequestTimeout = 30 * time.Second
+	httpRetryCount     = 5
+	httpRetryWaitTime  = 1 * time.Second
+
+	// httpRetryMaxWaitTime caps how long we will wait for a rate limit to reset before giving up on a request.
+	httpRetryMaxWaitTime = 5 * time.Minute
+)
+
+// APIError is returned when a platform API responds with a status that the caller did not expect.
+type APIError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s %s returned status %s: %s", e.Method, e.URL, e.Status, e.Body)
+}
+
+func newAPIError(response *resty.Response) error {
+	body := string(response.Body())
+	if len(body) > 500 {
+		body = body[:500] + "..."
+	}
+
+	return &APIError{
+		Method:     response.Request.Method,
+		URL:        response.Request.URL,
+		StatusCode: response.StatusCode(),
+		Status:     response.Status(),
+		Body:       body,
+	}
+}
+
+// newHTTPClient builds the client shared by all requests to a single platform, with retries for rate limits and transient failures. Every attempt is recorded in the platform request metrics.
+func newHTTPClient(baseURL string) *resty.Client {
+	client := resty.New()
+	client.SetTimeout(httpRequestTimeout)
+	client.SetRetryCount(httpRetryCount)
+	client.SetRetryWaitTime(httpRetryWaitTime)
+	client.SetRetryMaxWaitTime(httpRetryMaxWaitTime)
+	client.AddRetryCondition(shouldRetry)
+	client.SetRetryAfter(retryAfter)
+	client.AddRetryHook(func(response *resty.Response, err error) {
+		if err != nil {
+			slog.Warn("platform request failed - retrying", "error", err)
+		} else if response != nil {
+			slog.Warn("platform request failed - retrying", "url", response.Request.URL, "status", response.Status())
+		}
+	})
+	client.OnAfterResponse(func(_ *resty.Client, response *resty.Response) error {
+		metrics.RecordPlatformRequest(baseURL, response.Request.Method, response.StatusCode(), response.Time(), response.Header())
+		return nil
+	})
+	client.OnError(func(request *resty.Request, err error) {
+		// requests that got a response have already been recorded
+		var responseErr *resty.ResponseError
+		if !errors.As(err, &responseErr) {
+			metrics.RecordPlatformRequest(baseURL, request.Method, 0, time.Since(request.Time), nil)
+		}
+	})
+
+	return client
+}
+
+func shouldRetry(response *resty.Response, err error) bool {
+	// requests that create or modify things are only retried when we know the platform didn't process them
+	idempotent := response == nil || response.Request.Method != http.MethodPost && response.Request.Method != http.MethodPatch
+
+	if err != nil {
+		// network-level failure
+		return idempotent
+	}
+
+	if response == nil {
+		return false
+	}
+
+	switch response.StatusCode() {
+	case http.StatusTooManyRequests:
+		return true
+
+	case http.StatusForbidden:
+		// GitHub signals primary rate limits with a 403 and an exhausted quota, and secondary rate limits with a 403 and a retry-after header
+		return response.Header().Get("X-RateLimit-Remaining") == "0" || response.Header().Get("Retry-After") != ""
+
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return idempotent
+	}
+
+	return false
+}
+
+func retryAfter(_ *resty.Client, response *resty.Response) (time.Duration, error) {
+	if response == nil {
+		return 0, nil
+	}
+
+	if raw := response.Header().Get("Retry-After"); raw != "" {
+		if seconds, err := strconv.Atoi(raw); err == nil {
+			return checkRetryWait(time.Duration(seconds) * time.Second)
+		}
+
+		if date, err := http.ParseTime(raw); err == nil {
+			return checkRetryWait(time.Until(date))
+		}
+	}
+
+	if response.Header().Get("X-RateLimit-Remaining") == "0" {
+		if raw := response.Header().Get("X-RateLimit-Reset"); raw != "" {
+			if epoch, err := strconv.ParseInt(raw, 10, 64); err == nil {
+				return checkRetryWait(time.Until(time.Unix(epoch, 0)))
+			}
+		}
+	}
+
+	// fall back to the default exponential backoff
+	return 0, nil
+}
+
+func checkRetryWait(wait time.Duration) (time.Duration, error) {
+	if wait > httpRetryMaxWaitTime {
+		return 0, fmt.Errorf("platform rate limit will not reset for %v, which is longer than the maximum wait of %v", wait.Round(time.Second), httpRetryMaxWaitTime)
+	}
+
+	if wait <= 0 {
+		// the limit has already reset
+		return httpRetryWaitTime, nil
+	}
+
+	return wait, nil
+}
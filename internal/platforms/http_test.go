@@ -0,0 +1,174 @@
+package platforms
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+func testResponse(method string, status int, headers map[string]string) *resty.Response {
+	header := http.Header{}
+	for k, v := range headers {
+		header.Set(k, v)
+	}
+
+	return &resty.Response{
+		Request:     &resty.Request{Method: method},
+		RawResponse: &http.Response{StatusCode: status, Header: header},
+	}
+}
+
+func TestShouldRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		response *resty.Response
+		err      error
+		want     bool
+	}{
+		{name: "success", response: testResponse(http.MethodGet, http.StatusOK, nil), want: false},
+		{name: "not found", response: testResponse(http.MethodGet, http.StatusNotFound, nil), want: false},
+		{name: "network failure without a response", response: nil, err: errors.New("connection reset"), want: true},
+		{name: "network failure on GET", response: testResponse(http.MethodGet, 0, nil), err: errors.New("connection reset"), want: true},
+		{name: "network failure on POST", response: testResponse(http.MethodPost, 0, nil), err: errors.New("connection reset"), want: false},
+		{name: "too many requests on GET", response: testResponse(http.MethodGet, http.StatusTooManyRequests, nil), want: true},
+		{name: "too many requests on POST", response: testResponse(http.MethodPost, http.StatusTooManyRequests, nil), want: true},
+		{name: "forbidden", response: testResponse(http.MethodGet, http.StatusForbidden, nil), want: false},
+		{name: "forbidden with exhausted quota", response: testResponse(http.MethodGet, http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0"}), want: true},
+		{name: "forbidden with remaining quota", response: testResponse(http.MethodGet, http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "10"}), want: false},
+		{name: "forbidden with retry-after", response: testResponse(http.MethodPatch, http.StatusForbidden, map[string]string{"Retry-After": "30"}), want: true},
+		{name: "server error on GET", response: testResponse(http.MethodGet, http.StatusInternalServerError, nil), want: true},
+		{name: "bad gateway on DELETE", response: testResponse(http.MethodDelete, http.StatusBadGateway, nil), want: true},
+		{name: "unavailable on POST", response: testResponse(http.MethodPost, http.StatusServiceUnavailable, nil), want: false},
+		{name: "gateway timeout on PATCH", response: testResponse(http.MethodPatch, http.StatusGatewayTimeout, nil), want: false},
+		{name: "not implemented", response: testResponse(http.MethodGet, http.StatusNotImplemented, nil), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := shouldRetry(tt.response, tt.err)
+			if got != tt.want {
+				t.Errorf("shouldRetry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		response *resty.Response
+		wantMin  time.Duration
+		wantMax  time.Duration
+		wantErr  bool
+	}{
+		{name: "no response", response: nil},
+		{name: "no headers", response: testResponse(http.MethodGet, http.StatusServiceUnavailable, nil)},
+		{name: "retry-after seconds", response: testResponse(http.MethodGet, http.StatusTooManyRequests, map[string]string{"Retry-After": "30"}), wantMin: 30 * time.Second, wantMax: 30 * time.Second},
+		{name: "retry-after zero", response: testResponse(http.MethodGet, http.StatusTooManyRequests, map[string]string{"Retry-After": "0"}), wantMin: httpRetryWaitTime, wantMax: httpRetryWaitTime},
+		{name: "retry-after date", response: testResponse(http.MethodGet, http.StatusTooManyRequests, map[string]string{"Retry-After": now.Add(time.Minute).UTC().Format(http.TimeFormat)}), wantMin: 55 * time.Second, wantMax: time.Minute},
+		{name: "retry-after too long", response: testResponse(http.MethodGet, http.StatusTooManyRequests, map[string]string{"Retry-After": "3600"}), wantErr: true},
+		{name: "retry-after unparseable", response: testResponse(http.MethodGet, http.StatusTooManyRequests, map[string]string{"Retry-After": "soon"})},
+		{
+			name:     "rate limit reset",
+			response: testResponse(http.MethodGet, http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": strconv.FormatInt(now.Add(2*time.Minute).Unix(), 10)}),
+			wantMin:  110 * time.Second,
+			wantMax:  2 * time.Minute,
+		},
+		{
+			name:     "rate limit already reset",
+			response: testResponse(http.MethodGet, http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": strconv.FormatInt(now.Add(-time.Minute).Unix(), 10)}),
+			wantMin:  httpRetryWaitTime,
+			wantMax:  httpRetryWaitTime,
+		},
+		{
+			name:     "rate limit reset too far away",
+			response: testResponse(http.MethodGet, http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": strconv.FormatInt(now.Add(time.Hour).Unix(), 10)}),
+			wantErr:  true,
+		},
+		{
+			name:     "rate limit reset with remaining quota",
+			response: testResponse(http.MethodGet, http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "5", "X-RateLimit-Reset": strconv.FormatInt(now.Add(2*time.Minute).Unix(), 10)}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := retryAfter(nil, tt.response)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("retryAfter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got < tt.wantMin || got > tt.wantMax {
+				t.Errorf("retryAfter() = %v, want between %v and %v", got, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+// TestHTTPClientRetries sends real requests through the platform client, so that the retry condition, wait and hooks are checked together rather than one at a time.
+func TestHTTPClientRetries(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+
+		// a Retry-After of 0 keeps the test fast, as it waits for the minimum time between attempts
+		switch r.URL.Path {
+		case "/flaky":
+			if n == 1 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+
+		case "/rate-limited":
+			if n == 1 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+
+		case "/unavailable":
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := newHTTPClient(server.URL)
+
+	send := func(method string, path string) (int, int32) {
+		t.Helper()
+		requests.Store(0)
+
+		response, err := client.R().Execute(method, server.URL+path)
+		if err != nil {
+			t.Fatalf("%s %s error = %v", method, path, err)
+		}
+
+		return response.StatusCode(), requests.Load()
+	}
+
+	if status, attempts := send(http.MethodGet, "/flaky"); status != http.StatusOK || attempts != 2 {
+		t.Errorf("GET after a server error returned %d after %d attempts, want 200 after 2", status, attempts)
+	}
+
+	if status, attempts := send(http.MethodPost, "/rate-limited"); status != http.StatusOK || attempts != 2 {
+		t.Errorf("POST after a rate limit returned %d after %d attempts, want 200 after 2", status, attempts)
+	}
+
+	// the platform may have acted on a POST that failed with a server error, so it must not be sent again
+	if status, attempts := send(http.MethodPost, "/unavailable"); status != http.StatusServiceUnavailable || attempts != 1 {
+		t.Errorf("POST to an unavailable server returned %d after %d attempts, want 503 after 1", status, attempts)
+	}
+}
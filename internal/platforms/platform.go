@@ -4,11 +4,15 @@ import (
 	"fmt"
 	"log/slog"
 	urllib "net/url"
+	"sync"
 
 	"github.com/markormesher/tedium/internal/schema"
 )
 
-var platformCache []Platform
+var (
+	platformCache     []Platform
+	platformCacheLock sync.RWMutex
+)
 
 type Platform interface {
 	Init(conf schema.TediumConfig) error
@@ -21,13 +25,45 @@ type Platform interface {
 	Profile() schema.PlatformProfile
 	AuthToken() string
 
+	// JobAuth returns a copy of the platform's auth config for a job that is about to start, refreshing any short-lived token first.
+	JobAuth() (*schema.AuthConfig, error)
+
 	DiscoverRepos() ([]schema.Repo, error)
 	RepoHasTediumConfig(repo schema.Repo) (bool, error)
 	ReadRepoFile(repo schema.Repo, branch string, pathCandidates []string) ([]byte, error)
-	OpenOrUpdatePullRequest(job schema.Job) error
+	OpenOrUpdatePullRequest(job schema.Job) (schema.PullRequest, error)
+
+	// FindPullRequest returns the open PR for the job's final branch, or nil if there isn't one.
+	FindPullRequest(job schema.Job) (*schema.PullRequest, error)
+
+	// FindIssue returns the open issue with the given title, or nil if there isn't one.
+	FindIssue(repo schema.Repo, title string) (*schema.Issue, error)
+
+	// FindIssuesByTitle returns the open issues with the given title that Tedium opened in any repo on the platform, keyed by the repo's full name. It costs far fewer requests than calling FindIssue for every repo, but may not include issues opened in the last few moments.
+	FindIssuesByTitle(title string) (map[string]schema.Issue, error)
+
+	// OpenOrUpdateIssue makes sure there is an open issue with the given title and body, given the existing issue found by FindIssue (if any). It doesn't change the issue if it is already up to date.
+	OpenOrUpdateIssue(repo schema.Repo, existing *schema.Issue, title string, body string) (schema.Issue, error)
+
+	// CloseIssue closes an issue.
+	CloseIssue(repo schema.Repo, number int) error
+
+	// SetCommitStatus publishes a status on the head commit of a branch, replacing any previous status with the same context. It does nothing if the branch doesn't exist.
+	SetCommitStatus(repo schema.Repo, branch string, status schema.CommitStatus) error
+}
+
+// ClearCache forgets all platforms, so that the next run can build them from fresh config.
+func ClearCache() {
+	platformCacheLock.Lock()
+	defer platformCacheLock.Unlock()
+
+	platformCache = nil
 }
 
 func FromURL(url string) Platform {
+	platformCacheLock.RLock()
+	defer platformCacheLock.RUnlock()
+
 	for _, platform := range platformCache {
 		if _, accepted := platform.AcceptsURL(url); accepted {
 			return platform
@@ -67,6 +103,9 @@ func FromConfig(conf schema.TediumConfig, platformConfig schema.PlatformConfig)
 	}
 
 	if platform != nil {
+		platformCacheLock.Lock()
+		defer platformCacheLock.Unlock()
+
 		platformCache = append(platformCache, platform)
 		return platform, nil
 	}
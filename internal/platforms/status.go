@@ -0,0 +1,59 @@
+package platforms
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"github.com/markormesher/tedium/internal/schema"
+)
+
+// maxStatusDescriptionLength is the longest description that GitHub accepts for a commit status.
+const maxStatusDescriptionLength = 140
+
+// SetJobStatus publishes a status for a job on the head commit of its PR branch, if commit statuses are enabled.
+func SetJobStatus(platform Platform, job schema.Job, state string, description string) error {
+	conf := job.Config.CommitStatuses
+	if !conf.Enabled {
+		return nil
+	}
+
+	source := job.Chore.SourceConfig.URL + "#" + job.Chore.SourceConfig.Directory
+	if job.Chore.SourceConfig.Branch != "" {
+		source += "@" + job.Chore.SourceConfig.Branch
+	}
+
+	description = fmt.Sprintf("%s (%s)", description, source)
+	// the limit is in characters, and cutting part-way through a multi-byte character would leave invalid UTF-8
+	if runes := []rune(description); len(runes) > maxStatusDescriptionLength {
+		description = string(runes[:maxStatusDescriptionLength-3]) + "..."
+	}
+
+	status := schema.CommitStatus{
+		State:       state,
+		Context:     "tedium/" + job.Chore.Name,
+		Description: description,
+	}
+
+	if conf.LogsURL != "" {
+		tmpl, err := template.New("logsURL").Parse(conf.LogsURL)
+		if err != nil {
+			return fmt.Errorf("error parsing logs URL template: %w", err)
+		}
+
+		var logsURL bytes.Buffer
+		err = tmpl.Execute(&logsURL, map[string]string{
+			"RunID":     job.Config.RunID,
+			"JobID":     job.ID,
+			"Repo":      job.Repo.FullName(),
+			"ChoreName": job.Chore.Name,
+		})
+		if err != nil {
+			return fmt.Errorf("error executing logs URL template: %w", err)
+		}
+
+		status.TargetURL = logsURL.String()
+	}
+
+	return platform.SetCommitStatus(job.Repo, job.FinalBranchName, status)
+}
@@ -0,0 +1,65 @@
+package platforms
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/markormesher/tedium/internal/schema"
+)
+
+// Descriptions are cut to GitHub's limit in characters, never part-way through a multi-byte character.
+func TestSetJobStatusTruncatesByCharacter(t *testing.T) {
+	var posted string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(`{"commit": {"id": "abc123"}}`))
+			return
+		}
+
+		var body struct {
+			Description string `json:"description"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("error decoding status: %v", err)
+		}
+		posted = body.Description
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	p, err := giteaPlatformFromConfig(schema.PlatformConfig{
+		Type:    "gitea",
+		BaseURL: server.URL,
+		Auth:    &schema.AuthConfig{Type: schema.AuthConfigTypeUserToken, TokenString: "token"},
+	})
+	if err != nil {
+		t.Fatalf("error building platform: %v", err)
+	}
+
+	job := schema.Job{
+		Repo:            schema.Repo{OwnerName: "owner", Name: "repo"},
+		Chore:           schema.ChoreSpec{Name: "translate", SourceConfig: schema.RepoChoreConfig{URL: "https://example.com/owner/chores.git", Directory: "translate"}},
+		FinalBranchName: "tedium/translate",
+	}
+	job.Config.CommitStatuses.Enabled = true
+
+	// 2-byte characters put the byte limit part-way through a character
+	err = SetJobStatus(p, job, schema.CommitStatusFailure, strings.Repeat("é", 200))
+	if err != nil {
+		t.Fatalf("SetJobStatus() error = %v", err)
+	}
+
+	if !utf8.ValidString(posted) {
+		t.Errorf("description is not valid UTF-8: %q", posted)
+	}
+
+	if n := utf8.RuneCountInString(posted); n != maxStatusDescriptionLength || !strings.HasSuffix(posted, "é...") {
+		t.Errorf("description has %d characters and ends %q, want %d ending in an ellipsis", n, posted[len(posted)-6:], maxStatusDescriptionLength)
+	}
+}
@@ -2,7 +2,11 @@ package schema
 
 // NOTE: this file is referenced in the README - update any links if you move or rename this file.
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/markormesher/tedium/internal/utils"
+)
 
 type ChoreSpec struct {
 	Name             string      `json:"name" yaml:"name"`
@@ -13,6 +17,18 @@ type ChoreSpec struct {
 	SkipCloneStep    bool `json:"skipCloneStep" yaml:"skipCloneStep"`
 	SkipFinaliseStep bool `json:"skipFinaliseStep" yaml:"skipFinaliseStep"`
 
+	// TimeoutSeconds limits how long the whole chore may run for, including Tedium's own steps. If zero, the executor's default is used.
+	TimeoutSeconds int `json:"timeoutSeconds" yaml:"timeoutSeconds"`
+
+	// Kubernetes overrides the executor's default pod settings for this chore.
+	Kubernetes KubernetesPodConfig `json:"kubernetes" yaml:"kubernetes"`
+
+	// Caches defines directories that are persisted between runs of this chore, such as package manager caches.
+	Caches []ChoreCache `json:"caches" yaml:"caches"`
+
+	// Schedule is a cron expression (e.g. "@weekly") limiting how often this chore runs in daemon mode. If blank, the chore runs on every run.
+	Schedule string `json:"schedule" yaml:"schedule"`
+
 	// SourceConfig contains the original user-specified config that was resolved into this chore.
 	SourceConfig RepoChoreConfig `json:"internal_sourceConfig" yaml:"internal_sourceConfig"`
 }
@@ -22,6 +38,31 @@ type ChoreStep struct {
 	Command     string            `json:"command" yaml:"command"`
 	Environment map[string]string `json:"environment" yaml:"environment"`
 	Internal    bool              `json:"-"`
+
+	// TimeoutSeconds limits how long this step may run for. If zero, the step is only limited by the chore's timeout.
+	TimeoutSeconds int `json:"timeoutSeconds" yaml:"timeoutSeconds"`
+}
+
+// ChoreCache is a named directory that is kept between runs of the same chore. Caches are never shared between different chores.
+type ChoreCache struct {
+	Name string `json:"name" yaml:"name"`
+
+	// MountPath is where the cache will be mounted in every step of the chore.
+	MountPath string `json:"mountPath" yaml:"mountPath"`
+}
+
+// CacheKey identifies this chore for the purposes of scoping caches.
+func (choreSpec *ChoreSpec) CacheKey() string {
+	return utils.SHA256String(choreSpec.SourceConfig.URL + "#" + choreSpec.SourceConfig.Directory)[:16]
+}
+
+// EffectiveSchedule returns the schedule for this chore, taking into account any override from the repo config.
+func (choreSpec *ChoreSpec) EffectiveSchedule() string {
+	if choreSpec.SourceConfig.Schedule != "" {
+		return choreSpec.SourceConfig.Schedule
+	}
+
+	return choreSpec.Schedule
 }
 
 func (choreSpec *ChoreSpec) CommitMessage() string {
@@ -7,8 +7,12 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"slices"
+	"strings"
+	"text/template"
 
 	"github.com/markormesher/tedium/internal/utils"
+	"github.com/robfig/cron/v3"
 	"gopkg.in/yaml.v3"
 )
 
@@ -17,12 +21,45 @@ type TediumConfig struct {
 	// Version stores the version of Tedium itself.
 	Version string
 
+	// RunID identifies a single run of Tedium. It is generated when the run starts.
+	RunID string
+
 	// Executor defines the actual executor that will be used to perform chores.
 	Executor ExecutorConfig `json:"executor" yaml:"executor"`
 
 	// Platforms defines the set of repository hosting platforms that repos will be discovered from.
 	Platforms []PlatformConfig `json:"platforms" yaml:"platforms"`
 
+	// Discovery defines how target repos are discovered and how their config is resolved.
+	Discovery DiscoveryConfig `json:"discovery" yaml:"discovery"`
+
+	// Daemon defines how Tedium behaves when it is running as a long-lived process with `tedium serve`.
+	Daemon DaemonConfig `json:"daemon" yaml:"daemon"`
+
+	// FailureThresholds defines how many failures a run tolerates before it is considered to have failed.
+	FailureThresholds FailureThresholdsConfig `json:"failureThresholds" yaml:"failureThresholds"`
+
+	// Metrics defines how Prometheus metrics are published. In daemon mode they are always served at /metrics on the daemon's listen address.
+	Metrics MetricsConfig `json:"metrics" yaml:"metrics"`
+
+	// Tracing defines how OpenTelemetry traces are exported.
+	Tracing TracingConfig `json:"tracing" yaml:"tracing"`
+
+	// Dashboard defines the issue that Tedium maintains in each repo to summarise its chores.
+	Dashboard DashboardConfig `json:"dashboard" yaml:"dashboard"`
+
+	// ConfigErrorIssue defines the issue that Tedium opens in a repo when its config can't be resolved.
+	ConfigErrorIssue ConfigErrorIssueConfig `json:"configErrorIssue" yaml:"configErrorIssue"`
+
+	// CommitStatuses defines the statuses that Tedium publishes on the head commit of its PR branches.
+	CommitStatuses CommitStatusesConfig `json:"commitStatuses" yaml:"commitStatuses"`
+
+	// Notifiers defines where run summaries are sent after every run.
+	Notifiers []NotifierConfig `json:"notifiers" yaml:"notifiers"`
+
+	// Reports defines machine-readable reports that are written at the end of every run.
+	Reports ReportsConfig `json:"reports" yaml:"reports"`
+
 	// Images defines the container images used for Tedium-owned stages of execution
 	Images struct {
 		Tedium string `json:"tedium" yaml:"tedium"`
@@ -35,6 +72,99 @@ type TediumConfig struct {
 	} `json:"autoEnrollment" yaml:"autoEnrollment"`
 }
 
+// DiscoveryConfig defines how target repos are discovered.
+type DiscoveryConfig struct {
+	// Concurrency defines how many repos Tedium should check and resolve config for concurrently. Defaults to 1.
+	Concurrency int `json:"concurrency" yaml:"concurrency"`
+}
+
+// FailureThresholdsConfig defines how many failures a run tolerates. A failed run causes `tedium run` to exit with a non-zero status. With the defaults, any failure fails the run.
+type FailureThresholdsConfig struct {
+	// MaxFailedJobsPercent is the percentage of jobs that may fail or time out without failing the run. Defaults to 0.
+	MaxFailedJobsPercent float64 `json:"maxFailedJobsPercent" yaml:"maxFailedJobsPercent"`
+
+	// MaxFailedRepos is the number of repos whose config may fail to resolve without failing the run. Defaults to 0.
+	MaxFailedRepos int `json:"maxFailedRepos" yaml:"maxFailedRepos"`
+}
+
+// DashboardConfig defines the dashboard issue that Tedium maintains in every repo that has a Tedium config.
+type DashboardConfig struct {
+	// Enabled turns on dashboard issues. Defaults to false.
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Title is the title of the dashboard issue. Tedium finds existing dashboards by their title, so changing it will leave old dashboards behind. Defaults to "Tedium Dashboard".
+	Title string `json:"title" yaml:"title"`
+}
+
+// ConfigErrorIssueConfig defines the issue that Tedium opens in a repo when its config can't be resolved, and closes once it can be resolved again.
+type ConfigErrorIssueConfig struct {
+	// Enabled turns on config error issues. Defaults to false.
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Title is the title of the issue. Tedium finds existing issues by their title, so changing it will leave old issues open. Defaults to "Tedium config error".
+	Title string `json:"title" yaml:"title"`
+}
+
+// CommitStatusesConfig defines the statuses that Tedium publishes on the head commit of its PR branches. On GitHub these are check runs when Tedium is authenticated as an app.
+type CommitStatusesConfig struct {
+	// Enabled turns on commit statuses. Defaults to false.
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// LogsURL is a Go template for the link attached to each status, executed with the run ID, job ID, repo and chore name (e.g. "https://tedium.example.com/runs/{{.RunID}}"). If blank, statuses have no link.
+	LogsURL string `json:"logsUrl" yaml:"logsUrl"`
+}
+
+// MetricsConfig defines how Prometheus metrics are published.
+type MetricsConfig struct {
+	// PushgatewayURL is a Pushgateway-compatible endpoint that metrics are pushed to at the end of `tedium run`. If blank, metrics are not pushed.
+	PushgatewayURL string `json:"pushgatewayUrl" yaml:"pushgatewayUrl"`
+}
+
+// TracingConfig defines how OpenTelemetry traces are exported.
+type TracingConfig struct {
+	// OTLPEndpoint is the URL of an OTLP/HTTP endpoint to export traces to (e.g. "http://otel-collector:4318"). If blank, tracing is disabled. It is also used by Tedium's own steps within executor jobs, so it must be reachable from there too.
+	OTLPEndpoint string `json:"otlpEndpoint" yaml:"otlpEndpoint"`
+}
+
+// ReportsConfig defines where run reports are written. Reports are not written if their path is blank.
+type ReportsConfig struct {
+	// JSONPath is where a JSON report describing every repo and job in the run is written.
+	JSONPath string `json:"jsonPath" yaml:"jsonPath"`
+
+	// JUnitPath is where a JUnit XML report is written, with one test suite per repo and one test case per job.
+	JUnitPath string `json:"junitPath" yaml:"junitPath"`
+}
+
+// DaemonConfig defines how Tedium behaves in daemon mode.
+type DaemonConfig struct {
+	// Schedule is a cron expression (e.g. "0 * * * *" or "@hourly") defining when runs start. Chores with their own schedule are only executed on runs where they are due, so this should be at least as frequent as the most frequent chore schedule. Required in daemon mode.
+	Schedule string `json:"schedule" yaml:"schedule"`
+
+	// ListenAddress is where the daemon's HTTP server listens (e.g. ":8080"). The server receives webhooks from platforms and serves the API and status page. If blank, no server is started.
+	ListenAddress string `json:"listenAddress" yaml:"listenAddress"`
+
+	// APIToken must be provided as a bearer token to use the API and status page. If blank, they are disabled (except as allowed by PublicStatus). It is never serialised into jobs, because executors don't need it.
+	APIToken     string `json:"-" yaml:"apiToken"`
+	APITokenFile string `json:"apiTokenFile" yaml:"apiTokenFile"`
+
+	// PublicStatus makes the status page and read-only API endpoints available without the API token. Triggering runs always requires the token.
+	PublicStatus bool `json:"publicStatus" yaml:"publicStatus"`
+}
+
+// ReadAPIToken returns the daemon's API token, reading it from a file if necessary.
+func (dc DaemonConfig) ReadAPIToken() (string, error) {
+	if dc.APIToken != "" || dc.APITokenFile == "" {
+		return dc.APIToken, nil
+	}
+
+	token, err := os.ReadFile(dc.APITokenFile)
+	if err != nil {
+		return "", fmt.Errorf("error reading API token: %w", err)
+	}
+
+	return strings.TrimSpace(string(token)), nil
+}
+
 // RepoConfig is read from a target repo. The main purpose is to define which chores are to be applied.
 type RepoConfig struct {
 	Extends []string          `json:"extends,omitempty" yaml:"extends,omitempty"`
@@ -54,11 +184,17 @@ type RepoChoreConfig struct {
 
 	// ExposePlatformToken specifies that the target repo's platform auth token should be exposed to chore steps via the TEDIUM_PLATFORM_TOKEN environment variable. Use with caution.
 	ExposePlatformToken bool `json:"exposePlatformToken" yaml:"exposePlatformToken"`
+
+	// Schedule overrides the schedule defined by the chore itself. Only used in daemon mode.
+	Schedule string `json:"schedule" yaml:"schedule"`
 }
 
 // ResolvedRepoConfig is the result of taking a target repo, following all "extends" links, and resolving all chore references into their actual spec.
 type ResolvedRepoConfig struct {
 	Chores []ChoreSpec
+
+	// SourceURLs lists every other repo that was read while resolving the config (i.e. extended configs and chore definitions).
+	SourceURLs []string
 }
 
 // ---
@@ -104,12 +240,105 @@ func LoadTediumConfig(configFilePath string, version string) (TediumConfig, erro
 		conf.Executor.ChoreConcurrency = 1
 	}
 
+	if conf.Executor.Retries.Count < 0 {
+		conf.Executor.Retries.Count = 0
+	}
+
+	if conf.Executor.Retries.BackoffSeconds <= 0 {
+		conf.Executor.Retries.BackoffSeconds = 30
+	}
+
+	if conf.Executor.Retries.RetryOn == nil {
+		conf.Executor.Retries.RetryOn = []string{FailureClassImagePull, FailureClassClone, FailureClassInfrastructure}
+	}
+
+	if conf.Discovery.Concurrency < 1 {
+		conf.Discovery.Concurrency = 1
+	}
+
 	if conf.Executor.Kubernetes.JobTTLSeconds <= 0 {
 		conf.Executor.Kubernetes.JobTTLSeconds = 43200
 	}
 
+	if conf.Executor.Kubernetes.ChoreTimeoutSeconds <= 0 {
+		conf.Executor.Kubernetes.ChoreTimeoutSeconds = 3600
+	}
+
+	if conf.Executor.Kubernetes.OrphanedJobPolicy == "" {
+		conf.Executor.Kubernetes.OrphanedJobPolicy = OrphanedJobPolicyWait
+	}
+
+	if conf.Dashboard.Title == "" {
+		conf.Dashboard.Title = "Tedium Dashboard"
+	}
+
+	if conf.ConfigErrorIssue.Title == "" {
+		conf.ConfigErrorIssue.Title = "Tedium config error"
+	}
+
+	for i := range conf.Notifiers {
+		if conf.Notifiers[i].When == "" {
+			conf.Notifiers[i].When = NotifyAlways
+		}
+
+		if conf.Notifiers[i].SMTP.Port == 0 {
+			conf.Notifiers[i].SMTP.Port = 587
+		}
+	}
+
+	securityConf := &conf.Executor.Kubernetes.Pod.SecurityContext
+	if securityConf.SeccompProfile == "" {
+		securityConf.SeccompProfile = "RuntimeDefault"
+	}
+
+	if securityConf.AllowPrivilegeEscalation == nil {
+		securityConf.AllowPrivilegeEscalation = new(false)
+	}
+
 	// sanity checks
 
+	switch conf.Executor.Kubernetes.OrphanedJobPolicy {
+	case OrphanedJobPolicyWait, OrphanedJobPolicyDelete:
+		// ok
+	default:
+		return TediumConfig{}, fmt.Errorf("invalid Tedium config: unknown orphaned job policy %s", conf.Executor.Kubernetes.OrphanedJobPolicy)
+	}
+
+	for _, failureClass := range conf.Executor.Retries.RetryOn {
+		if !slices.Contains(AllFailureClasses, failureClass) {
+			return TediumConfig{}, fmt.Errorf("invalid Tedium config: unknown retry failure class %s", failureClass)
+		}
+	}
+
+	if conf.FailureThresholds.MaxFailedJobsPercent < 0 || conf.FailureThresholds.MaxFailedJobsPercent > 100 {
+		return TediumConfig{}, fmt.Errorf("invalid Tedium config: maxFailedJobsPercent must be between 0 and 100")
+	}
+
+	if conf.FailureThresholds.MaxFailedRepos < 0 {
+		return TediumConfig{}, fmt.Errorf("invalid Tedium config: maxFailedRepos must not be negative")
+	}
+
+	if conf.CommitStatuses.LogsURL != "" {
+		_, err := template.New("logsURL").Parse(conf.CommitStatuses.LogsURL)
+		if err != nil {
+			return TediumConfig{}, fmt.Errorf("invalid Tedium config: invalid commit status logs URL: %w", err)
+		}
+	}
+
+	for _, notifier := range conf.Notifiers {
+		err := notifier.validate()
+		if err != nil {
+			return TediumConfig{}, fmt.Errorf("invalid Tedium config: %w", err)
+		}
+	}
+
+	if conf.Daemon.Schedule != "" {
+		_, err := cron.ParseStandard(conf.Daemon.Schedule)
+		if err != nil {
+			return TediumConfig{}, fmt.Errorf("invalid Tedium config: invalid daemon schedule: %w", err)
+		}
+	}
+
 	urlsSeen := map[string]bool{}
 	for _, platform := range conf.Platforms {
 		allURLs := []string{platform.BaseURL}
@@ -1,15 +1,61 @@
 package schema
 
-// Event is used to relay progress between different goroutines.
-type Event int
+import "time"
+
+// EventType identifies what an Event describes.
+type EventType string
 
 const (
-	RepoDiscovered Event = iota
-	RepoFailed
-	RepoSkipped
-	DiscoveryFinished
-
-	JobDiscovered
-	JobSucceeded
-	JobFailed
+	RepoDiscovered    EventType = "repoDiscovered"
+	RepoFailed        EventType = "repoFailed"
+	RepoSkipped       EventType = "repoSkipped"
+	RepoResolved      EventType = "repoResolved"
+	DiscoveryFinished EventType = "discoveryFinished"
+
+	JobDiscovered EventType = "jobDiscovered"
+	JobStarted    EventType = "jobStarted"
+	JobRetried    EventType = "jobRetried"
+	JobSucceeded  EventType = "jobSucceeded"
+	JobFailed     EventType = "jobFailed"
+	JobTimedOut   EventType = "jobTimedOut"
 )
+
+// Event is used to relay progress between different goroutines, and to anything else that subscribes to a run's progress.
+type Event struct {
+	Type EventType `json:"type"`
+
+	// Time and RunID are filled in when the event is dispatched if the sender doesn't set them.
+	Time  time.Time `json:"time"`
+	RunID string    `json:"runID"`
+
+	// these fields are set when relevant to the event
+	Platform       string `json:"platform,omitempty"`
+	Repo           string `json:"repo,omitempty"`
+	ChoreName      string `json:"choreName,omitempty"`
+	JobID          string `json:"jobID,omitempty"`
+	Reason         string `json:"reason,omitempty"`
+	Error          string `json:"error,omitempty"`
+	PullRequestURL string `json:"pullRequestURL,omitempty"`
+
+	// Chores lists the names of the chores that apply to the repo (for RepoResolved events).
+	Chores []string `json:"chores,omitempty"`
+
+	// Job is set for events that are reported by the executor (from JobStarted onwards).
+	Job *JobResult `json:"job,omitempty"`
+}
+
+// NewJobEvent builds an event describing the state of a job.
+func NewJobEvent(eventType EventType, result JobResult) Event {
+	return Event{
+		Type:           eventType,
+		Time:           time.Now(),
+		RunID:          result.RunID,
+		Platform:       result.Platform,
+		Repo:           result.Repo,
+		ChoreName:      result.ChoreName,
+		JobID:          result.JobID,
+		Error:          result.Error,
+		PullRequestURL: result.PullRequestURL,
+		Job:            &result,
+	}
+}
@@ -4,9 +4,15 @@ package schema
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
 	"os"
+	"reflect"
+	"slices"
 	"strings"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ExecutorConfig defines the executor used to perform chores.
@@ -14,10 +20,56 @@ type ExecutorConfig struct {
 	// ChoreConcurrency defines how many chores Tedium should attempt to run concurrently. It is an upper bound and may not be reached. Defaults to 1.
 	ChoreConcurrency int `json:"concurrency" yaml:"concurrency"`
 
+	// Retries defines whether and how failed chores are retried.
+	Retries RetryConfig `json:"retries" yaml:"retries"`
+
 	// Kubernetes defines how to connect to the Kubernetes cluster for chore execution.
 	Kubernetes KubernetesConfig `json:"kubernetes" yaml:"kubernetes"`
 }
 
+// RetryConfig defines how failed chores are retried.
+type RetryConfig struct {
+	// Count is the maximum number of times a failed chore is retried. Defaults to 0 (no retries).
+	Count int `json:"count" yaml:"count"`
+
+	// BackoffSeconds is how long to wait before the first retry. It doubles for each subsequent retry. Defaults to 30.
+	BackoffSeconds int `json:"backoffSeconds" yaml:"backoffSeconds"`
+
+	// RetryOn lists the failure classes that are retried. Defaults to imagePull, clone and infrastructure.
+	RetryOn []string `json:"retryOn" yaml:"retryOn"`
+}
+
+// failure classes are used to decide whether a failed chore should be retried
+var (
+	// FailureClassImagePull means one of the chore's images could not be pulled.
+	FailureClassImagePull = "imagePull"
+
+	// FailureClassClone means the step that clones the target repo failed.
+	FailureClassClone = "clone"
+
+	// FailureClassChore means one of the chore's own steps failed.
+	FailureClassChore = "chore"
+
+	// FailureClassFinalise means the step that pushes changes and opens a PR failed.
+	FailureClassFinalise = "finalise"
+
+	// FailureClassTimeout means the chore or one of its steps exceeded its timeout.
+	FailureClassTimeout = "timeout"
+
+	// FailureClassInfrastructure covers everything else, e.g. errors talking to Kubernetes.
+	FailureClassInfrastructure = "infrastructure"
+
+	// FailureClassConfig means the chore's config can't be turned into a Kubernetes job, e.g. an invalid cache name. Retrying won't change the outcome, so it's left out of AllFailureClasses and can never be retried.
+	FailureClassConfig = "config"
+
+	AllFailureClasses = []string{FailureClassImagePull, FailureClassClone, FailureClassChore, FailureClassFinalise, FailureClassTimeout, FailureClassInfrastructure}
+)
+
+// ShouldRetry reports whether a failure of the given class should be retried.
+func (conf RetryConfig) ShouldRetry(failureClass string) bool {
+	return slices.Contains(conf.RetryOn, failureClass)
+}
+
 type KubernetesConfig struct {
 	// KubeconfigPath locates the configuration used to communicate with Kubernetes. If not supplied, the executable will assume it is running inside Kubernetes and will attempt to use the in-cluster config.
 	KubeconfigPath string `json:"kubeconfigPath" yaml:"kubeconfigPath"`
@@ -28,8 +80,297 @@ type KubernetesConfig struct {
 	// JobTTLSeconds defines the TTL assigned to exection jobs. Defaults to 43200 (12 hours).
 	JobTTLSeconds int `json:"jobTTLSeconds" yaml:"jobTTLSeconds"`
 
+	// ChoreTimeoutSeconds defines how long a chore may run for if it doesn't specify its own timeout. Defaults to 3600 (1 hour).
+	ChoreTimeoutSeconds int `json:"choreTimeoutSeconds" yaml:"choreTimeoutSeconds"`
+
 	// DeleteSuccessfulJobs defines whether successful jobs should be deleted immediately.
 	DeleteSuccessfulJobs bool `json:"deleteSuccessfulJobs" yaml:"deleteSuccessfulJobs"`
+
+	// OrphanedJobPolicy defines what happens to jobs left running by a previous run of Tedium (e.g. if it crashed). With "wait", any new job for the same repo and chore waits for the old one to finish; with "delete" old jobs are deleted at startup. Defaults to "wait".
+	OrphanedJobPolicy string `json:"orphanedJobPolicy" yaml:"orphanedJobPolicy"`
+
+	// DeleteJobsOnShutdown defines whether jobs that are still running should be deleted when Tedium is asked to shut down. If false they will be left to finish unobserved.
+	DeleteJobsOnShutdown bool `json:"deleteJobsOnShutdown" yaml:"deleteJobsOnShutdown"`
+
+	// ExtraLabels and ExtraAnnotations are added to every executor job and pod, in addition to the ones Tedium uses to identify them.
+	ExtraLabels      map[string]string `json:"extraLabels" yaml:"extraLabels"`
+	ExtraAnnotations map[string]string `json:"extraAnnotations" yaml:"extraAnnotations"`
+
+	// Pod defines the default scheduling and resource settings for chore pods. Chores can override these individually, within the limits set by ChoreOverrides.
+	Pod KubernetesPodConfig `json:"pod" yaml:"pod"`
+
+	// ChoreOverrides limits which pod settings chores can override.
+	ChoreOverrides KubernetesChoreOverridesConfig `json:"choreOverrides" yaml:"choreOverrides"`
+
+	// Caches defines the storage backing chore caches. If neither option is set, caches are not persisted between runs.
+	Caches KubernetesCacheConfig `json:"caches" yaml:"caches"`
+}
+
+type KubernetesCacheConfig struct {
+	// PersistentVolumeClaim names a PVC in the executor namespace to store caches in. If chores may run on several nodes at once it must support ReadWriteMany.
+	PersistentVolumeClaim string `json:"persistentVolumeClaim" yaml:"persistentVolumeClaim"`
+
+	// HostPath is a directory on each node to store caches in. It is only used if no PVC is set.
+	HostPath string `json:"hostPath" yaml:"hostPath"`
+}
+
+// KubernetesChoreOverridesConfig limits what chores can change about their pods. Chore definitions may come from repos that the operator doesn't control, so anything that grants extra privileges must be allowed here first; chores that ask for anything else fail without running.
+type KubernetesChoreOverridesConfig struct {
+	// AllowedServiceAccounts lists the service accounts that chores may run as, other than the default.
+	AllowedServiceAccounts []string `json:"allowedServiceAccounts" yaml:"allowedServiceAccounts"`
+
+	// AllowedCapabilities lists the Linux capabilities that chores may add.
+	AllowedCapabilities []string `json:"allowedCapabilities" yaml:"allowedCapabilities"`
+
+	// AllowedPriorityClasses lists the priority classes that chores may use, other than the default.
+	AllowedPriorityClasses []string `json:"allowedPriorityClasses" yaml:"allowedPriorityClasses"`
+
+	// AllowedTolerations lists the taint keys that chores may tolerate.
+	AllowedTolerations []string `json:"allowedTolerations" yaml:"allowedTolerations"`
+
+	// AllowNodeSelection lets chores replace the default node selector and affinity, which decide which nodes they can run on. Defaults to false.
+	AllowNodeSelection bool `json:"allowNodeSelection" yaml:"allowNodeSelection"`
+
+	// AllowWeakerSecurity lets chores weaken the default security context, e.g. by running as root, changing the group that owns shared volumes or keeping capabilities that the defaults drop. Defaults to false.
+	AllowWeakerSecurity bool `json:"allowWeakerSecurity" yaml:"allowWeakerSecurity"`
+}
+
+var (
+	OrphanedJobPolicyWait   = "wait"
+	OrphanedJobPolicyDelete = "delete"
+)
+
+// KubernetesPodConfig controls where chore pods are scheduled and what resources they can use.
+type KubernetesPodConfig struct {
+	// Resources defines the requests and limits applied to every container in the pod, using Kubernetes quantity strings (e.g. "500m", "1Gi").
+	Resources *KubernetesResourcesConfig `json:"resources,omitempty" yaml:"resources,omitempty"`
+
+	NodeSelector map[string]string      `json:"nodeSelector,omitempty" yaml:"nodeSelector,omitempty"`
+	Tolerations  []KubernetesToleration `json:"tolerations,omitempty" yaml:"tolerations,omitempty"`
+
+	// Affinity is passed through to the pod spec as-is, so it uses the same structure as a Kubernetes pod's affinity.
+	Affinity map[string]any `json:"affinity,omitempty" yaml:"affinity,omitempty"`
+
+	PriorityClassName  string `json:"priorityClassName,omitempty" yaml:"priorityClassName,omitempty"`
+	ServiceAccountName string `json:"serviceAccountName,omitempty" yaml:"serviceAccountName,omitempty"`
+
+	// ImagePullSecrets names secrets in the executor namespace used to pull chore images from private registries. Unlike other fields, per-chore values are added to the defaults rather than replacing them, because the defaults may be needed to pull Tedium's own image.
+	ImagePullSecrets []string `json:"imagePullSecrets,omitempty" yaml:"imagePullSecrets,omitempty"`
+
+	// SecurityContext is applied to chore pods. Only seccomp and privilege escalation are restricted by default. Chores can override individual fields, but only in ways that make it stricter unless the operator allows otherwise.
+	SecurityContext KubernetesSecurityConfig `json:"securityContext" yaml:"securityContext"`
+}
+
+// KubernetesSecurityConfig defines the security context applied to chore pods and every container within them. Unset fields are left to the image or cluster defaults.
+type KubernetesSecurityConfig struct {
+	RunAsNonRoot *bool  `json:"runAsNonRoot,omitempty" yaml:"runAsNonRoot,omitempty"`
+	RunAsUser    *int64 `json:"runAsUser,omitempty" yaml:"runAsUser,omitempty"`
+	RunAsGroup   *int64 `json:"runAsGroup,omitempty" yaml:"runAsGroup,omitempty"`
+
+	// FSGroup is applied to the shared repo volume, which allows steps running as different users to all modify the repo.
+	FSGroup *int64 `json:"fsGroup,omitempty" yaml:"fsGroup,omitempty"`
+
+	// SeccompProfile is "RuntimeDefault" or "Unconfined". Defaults to "RuntimeDefault".
+	SeccompProfile string `json:"seccompProfile,omitempty" yaml:"seccompProfile,omitempty"`
+
+	// ReadOnlyRootFilesystem makes each container's root filesystem read-only. A writable /tmp is provided when this is enabled.
+	ReadOnlyRootFilesystem *bool `json:"readOnlyRootFilesystem,omitempty" yaml:"readOnlyRootFilesystem,omitempty"`
+
+	// AllowPrivilegeEscalation defaults to false.
+	AllowPrivilegeEscalation *bool `json:"allowPrivilegeEscalation,omitempty" yaml:"allowPrivilegeEscalation,omitempty"`
+
+	DropCapabilities []string `json:"dropCapabilities,omitempty" yaml:"dropCapabilities,omitempty"`
+	AddCapabilities  []string `json:"addCapabilities,omitempty" yaml:"addCapabilities,omitempty"`
+}
+
+type KubernetesResourcesConfig struct {
+	Requests map[string]string `json:"requests,omitempty" yaml:"requests,omitempty"`
+	Limits   map[string]string `json:"limits,omitempty" yaml:"limits,omitempty"`
+}
+
+type KubernetesToleration struct {
+	Key               string `json:"key,omitempty" yaml:"key,omitempty"`
+	Operator          string `json:"operator,omitempty" yaml:"operator,omitempty"`
+	Value             string `json:"value,omitempty" yaml:"value,omitempty"`
+	Effect            string `json:"effect,omitempty" yaml:"effect,omitempty"`
+	TolerationSeconds *int64 `json:"tolerationSeconds,omitempty" yaml:"tolerationSeconds,omitempty"`
+}
+
+func (t KubernetesToleration) equal(other KubernetesToleration) bool {
+	return t.Key == other.Key && t.Operator == other.Operator && t.Value == other.Value && t.Effect == other.Effect && reflect.DeepEqual(t.TolerationSeconds, other.TolerationSeconds)
+}
+
+// CheckOverrides returns an error if a chore's overrides would grant it anything that the operator hasn't allowed.
+func (c KubernetesPodConfig) CheckOverrides(override KubernetesPodConfig, allowed KubernetesChoreOverridesConfig) error {
+	var errs []error
+
+	if override.ServiceAccountName != "" && override.ServiceAccountName != c.ServiceAccountName && !slices.Contains(allowed.AllowedServiceAccounts, override.ServiceAccountName) {
+		errs = append(errs, fmt.Errorf("service account %s is not allowed", override.ServiceAccountName))
+	}
+
+	if override.PriorityClassName != "" && override.PriorityClassName != c.PriorityClassName && !slices.Contains(allowed.AllowedPriorityClasses, override.PriorityClassName) {
+		errs = append(errs, fmt.Errorf("priority class %s is not allowed", override.PriorityClassName))
+	}
+
+	for _, toleration := range override.Tolerations {
+		if !slices.ContainsFunc(c.Tolerations, toleration.equal) && !slices.Contains(allowed.AllowedTolerations, toleration.Key) {
+			errs = append(errs, fmt.Errorf("toleration for %q is not allowed", toleration.Key))
+		}
+	}
+
+	if !allowed.AllowNodeSelection {
+		if override.NodeSelector != nil && !maps.Equal(override.NodeSelector, c.NodeSelector) {
+			errs = append(errs, fmt.Errorf("nodeSelector cannot be changed"))
+		}
+
+		if override.Affinity != nil {
+			errs = append(errs, fmt.Errorf("affinity cannot be changed"))
+		}
+	}
+
+	for _, capability := range override.SecurityContext.AddCapabilities {
+		if !slices.ContainsFunc(allowed.AllowedCapabilities, func(a string) bool { return strings.EqualFold(a, capability) }) {
+			errs = append(errs, fmt.Errorf("capability %s is not allowed", capability))
+		}
+	}
+
+	if !allowed.AllowWeakerSecurity {
+		errs = append(errs, c.SecurityContext.weakenedBy(override.SecurityContext)...)
+	}
+
+	return errors.Join(errs...)
+}
+
+// weakenedBy lists the ways in which an override would make the security context less strict.
+func (c KubernetesSecurityConfig) weakenedBy(override KubernetesSecurityConfig) []error {
+	var errs []error
+
+	isTrue := func(b *bool) bool { return b != nil && *b }
+	isFalse := func(b *bool) bool { return b != nil && !*b }
+
+	if isFalse(override.RunAsNonRoot) && isTrue(c.RunAsNonRoot) {
+		errs = append(errs, fmt.Errorf("runAsNonRoot cannot be disabled"))
+	}
+
+	if override.RunAsUser != nil && *override.RunAsUser == 0 && (c.RunAsUser == nil || *c.RunAsUser != 0) {
+		errs = append(errs, fmt.Errorf("runAsUser cannot be set to root"))
+	}
+
+	if override.RunAsGroup != nil && *override.RunAsGroup == 0 && (c.RunAsGroup == nil || *c.RunAsGroup != 0) {
+		errs = append(errs, fmt.Errorf("runAsGroup cannot be set to root"))
+	}
+
+	// fsGroup decides which group owns the pod's volumes, including the cache volume that holds every chore's caches
+	if override.FSGroup != nil && (c.FSGroup == nil || *override.FSGroup != *c.FSGroup) {
+		errs = append(errs, fmt.Errorf("fsGroup cannot be changed"))
+	}
+
+	if override.SeccompProfile != "" && override.SeccompProfile != c.SeccompProfile && c.SeccompProfile != "Unconfined" {
+		errs = append(errs, fmt.Errorf("seccompProfile cannot be changed from %s", c.SeccompProfile))
+	}
+
+	if isFalse(override.ReadOnlyRootFilesystem) && isTrue(c.ReadOnlyRootFilesystem) {
+		errs = append(errs, fmt.Errorf("readOnlyRootFilesystem cannot be disabled"))
+	}
+
+	if isTrue(override.AllowPrivilegeEscalation) && !isTrue(c.AllowPrivilegeEscalation) {
+		errs = append(errs, fmt.Errorf("allowPrivilegeEscalation cannot be enabled"))
+	}
+
+	if override.DropCapabilities != nil {
+		for _, capability := range c.DropCapabilities {
+			if !slices.ContainsFunc(override.DropCapabilities, func(o string) bool { return strings.EqualFold(o, capability) }) {
+				errs = append(errs, fmt.Errorf("capability %s must still be dropped", capability))
+			}
+		}
+	}
+
+	return errs
+}
+
+// WithOverrides returns a copy of the config with every field that is set in the override replacing the original value.
+func (c KubernetesPodConfig) WithOverrides(override KubernetesPodConfig) KubernetesPodConfig {
+	merged := c
+
+	if override.Resources != nil {
+		merged.Resources = override.Resources
+	}
+
+	if override.NodeSelector != nil {
+		merged.NodeSelector = override.NodeSelector
+	}
+
+	if override.Tolerations != nil {
+		merged.Tolerations = override.Tolerations
+	}
+
+	if override.Affinity != nil {
+		merged.Affinity = override.Affinity
+	}
+
+	if override.PriorityClassName != "" {
+		merged.PriorityClassName = override.PriorityClassName
+	}
+
+	if override.ServiceAccountName != "" {
+		merged.ServiceAccountName = override.ServiceAccountName
+	}
+
+	if override.ImagePullSecrets != nil {
+		merged.ImagePullSecrets = slices.Clone(c.ImagePullSecrets)
+		for _, secret := range override.ImagePullSecrets {
+			if !slices.Contains(merged.ImagePullSecrets, secret) {
+				merged.ImagePullSecrets = append(merged.ImagePullSecrets, secret)
+			}
+		}
+	}
+
+	merged.SecurityContext = c.SecurityContext.WithOverrides(override.SecurityContext)
+
+	return merged
+}
+
+// WithOverrides returns a copy of the config with every field that is set in the override replacing the original value.
+func (c KubernetesSecurityConfig) WithOverrides(override KubernetesSecurityConfig) KubernetesSecurityConfig {
+	merged := c
+
+	if override.RunAsNonRoot != nil {
+		merged.RunAsNonRoot = override.RunAsNonRoot
+	}
+
+	if override.RunAsUser != nil {
+		merged.RunAsUser = override.RunAsUser
+	}
+
+	if override.RunAsGroup != nil {
+		merged.RunAsGroup = override.RunAsGroup
+	}
+
+	if override.FSGroup != nil {
+		merged.FSGroup = override.FSGroup
+	}
+
+	if override.SeccompProfile != "" {
+		merged.SeccompProfile = override.SeccompProfile
+	}
+
+	if override.ReadOnlyRootFilesystem != nil {
+		merged.ReadOnlyRootFilesystem = override.ReadOnlyRootFilesystem
+	}
+
+	if override.AllowPrivilegeEscalation != nil {
+		merged.AllowPrivilegeEscalation = override.AllowPrivilegeEscalation
+	}
+
+	if override.DropCapabilities != nil {
+		merged.DropCapabilities = override.DropCapabilities
+	}
+
+	if override.AddCapabilities != nil {
+		merged.AddCapabilities = override.AddCapabilities
+	}
+
+	return merged
 }
 
 // ExecutionStep decouples the definition of a ChoreStep from the actual execution.
@@ -37,12 +378,22 @@ type ExecutionStep struct {
 	Image   string `json:"image" yaml:"image"`
 	Command string `json:"command" yaml:"command"`
 
-	Label       string
-	Environment map[string]string
+	Label          string
+	Stage          string
+	Environment    map[string]string
+	TimeoutSeconds int
 }
 
+// step stages identify which part of a chore an execution step belongs to
+var (
+	StepStageClone    = "clone"
+	StepStageChore    = "chore"
+	StepStageFinalise = "finalise"
+)
+
 // Job represents an item of work to be done: a specific chore on a specific repo. It should be self-contained; i.e. carry all the info needed to perform a job.
 type Job struct {
+	ID              string
 	Config          TediumConfig
 	Repo            Repo
 	Chore           ChoreSpec
@@ -50,10 +401,14 @@ type Job struct {
 	PlatformConfig  PlatformConfig
 	WorkBranchName  string
 	FinalBranchName string
+
+	// Span traces the job from when it is prepared until it finishes. It is only set within the process that prepared the job.
+	Span trace.Span `json:"-"`
 }
 
-// ToEnvironment bundles the Job into a single environment variable that can be unpacked later by the init and finalise stages of an execution.
-func (job *Job) ToEnvironment() (map[string]string, error) {
+// ToEnvironment bundles the Job into a single environment variable that can be unpacked later by the init and finalise stages of an execution. The execution steps are left out because those stages don't use them.
+func (job Job) ToEnvironment() (map[string]string, error) {
+	job.ExecutionSteps = nil
 	jobStrBytes, err := json.Marshal(job)
 	if err != nil {
 		return nil, fmt.Errorf("error marshalling Tedium config into environment variable: %w", err)
@@ -0,0 +1,143 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKubernetesPodConfigWithOverrides(t *testing.T) {
+	base := KubernetesPodConfig{
+		Resources:          &KubernetesResourcesConfig{Limits: map[string]string{"memory": "1Gi"}},
+		NodeSelector:       map[string]string{"pool": "default"},
+		PriorityClassName:  "low",
+		ServiceAccountName: "tedium",
+		ImagePullSecrets:   []string{"registry"},
+		SecurityContext: KubernetesSecurityConfig{
+			RunAsNonRoot:             new(true),
+			SeccompProfile:           "RuntimeDefault",
+			AllowPrivilegeEscalation: new(false),
+			DropCapabilities:         []string{"ALL"},
+		},
+	}
+
+	if got := base.WithOverrides(KubernetesPodConfig{}); !reflect.DeepEqual(got, base) {
+		t.Errorf("empty override changed the defaults to %+v", got)
+	}
+
+	got := base.WithOverrides(KubernetesPodConfig{
+		Resources:        &KubernetesResourcesConfig{Limits: map[string]string{"memory": "4Gi"}},
+		NodeSelector:     map[string]string{},
+		ImagePullSecrets: []string{"registry", "private"},
+		SecurityContext:  KubernetesSecurityConfig{RunAsUser: new(int64(1000)), ReadOnlyRootFilesystem: new(true)},
+	})
+
+	// fields that are set replace the defaults, even when they're empty maps
+	if got.Resources.Limits["memory"] != "4Gi" || len(got.NodeSelector) != 0 {
+		t.Errorf("overridden fields = %+v and %+v, want the chore's values", got.Resources, got.NodeSelector)
+	}
+
+	if got.PriorityClassName != "low" || got.ServiceAccountName != "tedium" {
+		t.Errorf("fields that weren't overridden = %q and %q, want the defaults", got.PriorityClassName, got.ServiceAccountName)
+	}
+
+	// image pull secrets are added to the defaults, and security fields are merged one by one
+	if !reflect.DeepEqual(got.ImagePullSecrets, []string{"registry", "private"}) {
+		t.Errorf("image pull secrets = %v, want the defaults plus the chore's, without duplicates", got.ImagePullSecrets)
+	}
+
+	wantSecurity := base.SecurityContext
+	wantSecurity.RunAsUser = new(int64(1000))
+	wantSecurity.ReadOnlyRootFilesystem = new(true)
+	if !reflect.DeepEqual(got.SecurityContext, wantSecurity) {
+		t.Errorf("security context = %+v, want %+v", got.SecurityContext, wantSecurity)
+	}
+
+	// merging must not modify the defaults, which are shared by every chore
+	if !reflect.DeepEqual(base.ImagePullSecrets, []string{"registry"}) {
+		t.Errorf("WithOverrides() modified the defaults: %v", base.ImagePullSecrets)
+	}
+}
+
+func TestKubernetesPodConfigCheckOverrides(t *testing.T) {
+	base := KubernetesPodConfig{
+		ServiceAccountName: "tedium",
+		NodeSelector:       map[string]string{"pool": "default"},
+		Tolerations:        []KubernetesToleration{{Key: "dedicated", Operator: "Equal", Value: "batch", Effect: "NoSchedule"}},
+		PriorityClassName:  "batch-low",
+		SecurityContext: KubernetesSecurityConfig{
+			FSGroup:                  new(int64(1000)),
+			RunAsNonRoot:             new(true),
+			SeccompProfile:           "RuntimeDefault",
+			ReadOnlyRootFilesystem:   new(true),
+			AllowPrivilegeEscalation: new(false),
+			DropCapabilities:         []string{"ALL"},
+		},
+	}
+
+	allowed := KubernetesChoreOverridesConfig{
+		AllowedServiceAccounts: []string{"deployer"},
+		AllowedCapabilities:    []string{"NET_BIND_SERVICE"},
+		AllowedPriorityClasses: []string{"batch-high"},
+		AllowedTolerations:     []string{"gpu"},
+	}
+
+	tests := []struct {
+		name     string
+		override KubernetesPodConfig
+		allowed  KubernetesChoreOverridesConfig
+		wantErr  bool
+	}{
+		{name: "no overrides", override: KubernetesPodConfig{}, allowed: allowed},
+		{name: "resources", override: KubernetesPodConfig{Resources: &KubernetesResourcesConfig{Limits: map[string]string{"memory": "8Gi"}}}, allowed: allowed},
+		{name: "allowed priority class", override: KubernetesPodConfig{PriorityClassName: "batch-high"}, allowed: allowed},
+		{name: "other priority class", override: KubernetesPodConfig{PriorityClassName: "system-cluster-critical"}, allowed: allowed, wantErr: true},
+		{name: "default tolerations", override: KubernetesPodConfig{Tolerations: []KubernetesToleration{{Key: "dedicated", Operator: "Equal", Value: "batch", Effect: "NoSchedule"}}}, allowed: allowed},
+		{name: "allowed toleration", override: KubernetesPodConfig{Tolerations: []KubernetesToleration{{Key: "gpu", Operator: "Exists"}}}, allowed: allowed},
+		{name: "other toleration", override: KubernetesPodConfig{Tolerations: []KubernetesToleration{{Key: "node-role.kubernetes.io/control-plane", Operator: "Exists"}}}, allowed: allowed, wantErr: true},
+		{name: "toleration for every taint", override: KubernetesPodConfig{Tolerations: []KubernetesToleration{{Operator: "Exists"}}}, allowed: allowed, wantErr: true},
+		{name: "same node selector", override: KubernetesPodConfig{NodeSelector: map[string]string{"pool": "default"}}, allowed: allowed},
+		{name: "node selector", override: KubernetesPodConfig{NodeSelector: map[string]string{"pool": "big"}}, allowed: allowed, wantErr: true},
+		{name: "affinity", override: KubernetesPodConfig{Affinity: map[string]any{"nodeAffinity": map[string]any{}}}, allowed: allowed, wantErr: true},
+		{
+			name:     "node selection when allowed",
+			override: KubernetesPodConfig{NodeSelector: map[string]string{"pool": "big"}, Affinity: map[string]any{"nodeAffinity": map[string]any{}}},
+			allowed:  KubernetesChoreOverridesConfig{AllowNodeSelection: true},
+		},
+		{name: "default service account", override: KubernetesPodConfig{ServiceAccountName: "tedium"}, allowed: allowed},
+		{name: "allowed service account", override: KubernetesPodConfig{ServiceAccountName: "deployer"}, allowed: allowed},
+		{name: "other service account", override: KubernetesPodConfig{ServiceAccountName: "cluster-admin"}, allowed: allowed, wantErr: true},
+		{name: "allowed capability", override: KubernetesPodConfig{SecurityContext: KubernetesSecurityConfig{AddCapabilities: []string{"net_bind_service"}}}, allowed: allowed},
+		{name: "other capability", override: KubernetesPodConfig{SecurityContext: KubernetesSecurityConfig{AddCapabilities: []string{"SYS_ADMIN"}}}, allowed: allowed, wantErr: true},
+		{name: "stricter security", override: KubernetesPodConfig{SecurityContext: KubernetesSecurityConfig{RunAsUser: new(int64(1000)), DropCapabilities: []string{"all", "NET_RAW"}}}, allowed: allowed},
+		{name: "same seccomp profile", override: KubernetesPodConfig{SecurityContext: KubernetesSecurityConfig{SeccompProfile: "RuntimeDefault"}}, allowed: allowed},
+		{name: "runAsNonRoot disabled", override: KubernetesPodConfig{SecurityContext: KubernetesSecurityConfig{RunAsNonRoot: new(false)}}, allowed: allowed, wantErr: true},
+		{name: "run as root", override: KubernetesPodConfig{SecurityContext: KubernetesSecurityConfig{RunAsUser: new(int64(0))}}, allowed: allowed, wantErr: true},
+		{name: "run as root group", override: KubernetesPodConfig{SecurityContext: KubernetesSecurityConfig{RunAsGroup: new(int64(0))}}, allowed: allowed, wantErr: true},
+		{name: "same fsGroup", override: KubernetesPodConfig{SecurityContext: KubernetesSecurityConfig{FSGroup: new(int64(1000))}}, allowed: allowed},
+		{name: "other fsGroup", override: KubernetesPodConfig{SecurityContext: KubernetesSecurityConfig{FSGroup: new(int64(0))}}, allowed: allowed, wantErr: true},
+		{name: "seccomp unconfined", override: KubernetesPodConfig{SecurityContext: KubernetesSecurityConfig{SeccompProfile: "Unconfined"}}, allowed: allowed, wantErr: true},
+		{name: "writable root filesystem", override: KubernetesPodConfig{SecurityContext: KubernetesSecurityConfig{ReadOnlyRootFilesystem: new(false)}}, allowed: allowed, wantErr: true},
+		{name: "privilege escalation", override: KubernetesPodConfig{SecurityContext: KubernetesSecurityConfig{AllowPrivilegeEscalation: new(true)}}, allowed: allowed, wantErr: true},
+		{name: "dropped capabilities removed", override: KubernetesPodConfig{SecurityContext: KubernetesSecurityConfig{DropCapabilities: []string{}}}, allowed: allowed, wantErr: true},
+		{
+			name:     "weaker security when allowed",
+			override: KubernetesPodConfig{SecurityContext: KubernetesSecurityConfig{RunAsNonRoot: new(false), SeccompProfile: "Unconfined", AllowPrivilegeEscalation: new(true)}},
+			allowed:  KubernetesChoreOverridesConfig{AllowWeakerSecurity: true},
+		},
+		{
+			name:     "weaker security doesn't allow capabilities",
+			override: KubernetesPodConfig{SecurityContext: KubernetesSecurityConfig{AddCapabilities: []string{"SYS_ADMIN"}}},
+			allowed:  KubernetesChoreOverridesConfig{AllowWeakerSecurity: true},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := base.CheckOverrides(tt.override, tt.allowed)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckOverrides() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
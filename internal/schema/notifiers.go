@@ -0,0 +1,116 @@
+package schema
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+var (
+	NotifierTypeWebhook = "webhook"
+	NotifierTypeSlack   = "slack"
+	NotifierTypeEmail   = "email"
+)
+
+var (
+	// NotifyAlways sends a notification after every run.
+	NotifyAlways = "always"
+
+	// NotifyOnChanges sends a notification after runs that opened or updated PRs, or had failures.
+	NotifyOnChanges = "changes"
+
+	// NotifyOnFailures sends a notification after runs that had failures.
+	NotifyOnFailures = "failures"
+)
+
+// NotifierConfig defines somewhere that a summary is sent after every run.
+type NotifierConfig struct {
+	// Type is one of "webhook", "slack" or "email".
+	Type string `json:"type" yaml:"type"`
+
+	// When is one of "always", "changes" or "failures". Defaults to "always".
+	When string `json:"when" yaml:"when"`
+
+	// Template is a Go text template for the message, executed with the run summary. For webhooks it should produce JSON; if blank the summary itself is sent as JSON.
+	Template string `json:"template" yaml:"template"`
+
+	// URL is where webhook and Slack notifications are posted. For Slack this is an incoming webhook URL, which should be kept secret, so it is never serialised into jobs.
+	URL     string `json:"-" yaml:"url"`
+	URLFile string `json:"urlFile" yaml:"urlFile"`
+
+	// SMTP is required for email notifications.
+	SMTP SMTPConfig `json:"smtp" yaml:"smtp"`
+}
+
+// SMTPConfig defines how email notifications are sent.
+type SMTPConfig struct {
+	Host string `json:"host" yaml:"host"`
+
+	// Port defaults to 587.
+	Port int `json:"port" yaml:"port"`
+
+	// Username and Password are used for authentication if Username is set. The server must support TLS for authentication to be used. Password is never serialised into jobs.
+	Username     string `json:"username" yaml:"username"`
+	Password     string `json:"-" yaml:"password"`
+	PasswordFile string `json:"passwordFile" yaml:"passwordFile"`
+
+	From string   `json:"from" yaml:"from"`
+	To   []string `json:"to" yaml:"to"`
+
+	// Subject is a Go text template for the email subject, executed with the run summary.
+	Subject string `json:"subject" yaml:"subject"`
+}
+
+// ReadURL returns the notifier's URL, reading it from a file if necessary.
+func (nc NotifierConfig) ReadURL() (string, error) {
+	if nc.URL != "" || nc.URLFile == "" {
+		return nc.URL, nil
+	}
+
+	url, err := os.ReadFile(nc.URLFile)
+	if err != nil {
+		return "", fmt.Errorf("error reading notifier URL: %w", err)
+	}
+
+	return strings.TrimSpace(string(url)), nil
+}
+
+// ReadPassword returns the SMTP password, reading it from a file if necessary.
+func (sc SMTPConfig) ReadPassword() (string, error) {
+	if sc.Password != "" || sc.PasswordFile == "" {
+		return sc.Password, nil
+	}
+
+	password, err := os.ReadFile(sc.PasswordFile)
+	if err != nil {
+		return "", fmt.Errorf("error reading SMTP password: %w", err)
+	}
+
+	return strings.TrimSpace(string(password)), nil
+}
+
+func (nc NotifierConfig) validate() error {
+	switch nc.When {
+	case NotifyAlways, NotifyOnChanges, NotifyOnFailures:
+		// ok
+	default:
+		return fmt.Errorf("unknown notifier condition %s", nc.When)
+	}
+
+	switch nc.Type {
+	case NotifierTypeWebhook, NotifierTypeSlack:
+		if nc.URL == "" && nc.URLFile == "" {
+			return fmt.Errorf("%s notifiers require a URL", nc.Type)
+		}
+
+	case NotifierTypeEmail:
+		if nc.SMTP.Host == "" || nc.SMTP.From == "" || len(nc.SMTP.To) == 0 {
+			return fmt.Errorf("email notifiers require an SMTP host, from address and at least one to address")
+		}
+
+	default:
+		return fmt.Errorf("unknown notifier type %s", nc.Type)
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -24,6 +25,60 @@ type PlatformConfig struct {
 	// RepoFiltersRaw specifies a list of Go regexes; if specified, only repos that match at least one filter will be processed.
 	RepoFiltersRaw []string `json:"repoFilters" yaml:"repoFilters"`
 	RepoFilters    []*regexp.Regexp
+
+	// WebhookSecret is used to verify push webhooks sent by this platform in daemon mode. Webhooks from platforms without a secret are rejected. It is never serialised into jobs, because executors don't need it.
+	WebhookSecret     string `json:"-" yaml:"webhookSecret"`
+	WebhookSecretFile string `json:"webhookSecretFile" yaml:"webhookSecretFile"`
+}
+
+// ReadWebhookSecret returns the platform's webhook secret, reading it from a file if necessary.
+func (pc PlatformConfig) ReadWebhookSecret() (string, error) {
+	if pc.WebhookSecret != "" || pc.WebhookSecretFile == "" {
+		return pc.WebhookSecret, nil
+	}
+
+	secret, err := os.ReadFile(pc.WebhookSecretFile)
+	if err != nil {
+		return "", fmt.Errorf("error reading webhook secret for %s: %w", pc.BaseURL, err)
+	}
+
+	return strings.TrimSpace(string(secret)), nil
+}
+
+// PullRequest identifies a PR opened or updated by Tedium.
+type PullRequest struct {
+	Number int    `json:"number"`
+	URL    string `json:"html_url"`
+
+	// Created is true if the PR was opened, rather than an existing PR being updated.
+	Created bool `json:"-"`
+}
+
+// Issue is an issue that Tedium maintains in a repo, such as the dashboard. Tedium identifies its issues by their title.
+type Issue struct {
+	Number int    `json:"number"`
+	URL    string `json:"html_url"`
+	Title  string `json:"title"`
+	Body   string `json:"body"`
+}
+
+var (
+	CommitStatusSuccess = "success"
+	CommitStatusFailure = "failure"
+)
+
+// CommitStatus is a status that Tedium publishes on the head commit of a chore's PR branch.
+type CommitStatus struct {
+	// State is one of "success" or "failure".
+	State string
+
+	// Context identifies the chore that the status is for, so that each chore's status is shown separately.
+	Context string
+
+	Description string
+
+	// TargetURL is linked from the status, if set.
+	TargetURL string
 }
 
 var (
@@ -45,6 +100,27 @@ type AuthConfig struct {
 	PrivateKeyFile       string `json:"privateKeyFile" yaml:"privateKeyFile"`
 	InstallationID       string `json:"installationID" yaml:"installationID"`
 	AppInstallationToken string `json:"doNotUse_appInstallationToken"`
+
+	// AppInstallationTokenExpiry is when AppInstallationToken stops working. Installation tokens only last an hour, so long-lived processes must regenerate them.
+	AppInstallationTokenExpiry time.Time `json:"doNotUse_appInstallationTokenExpiry"`
+}
+
+// Token returns the token used to authenticate with the platform, which is only known for app auth once an installation token has been generated.
+func (ac *AuthConfig) Token() string {
+	if ac == nil {
+		return ""
+	}
+
+	switch ac.Type {
+	case AuthConfigTypeUserToken:
+		return ac.TokenString
+
+	case AuthConfigTypeApp:
+		return ac.AppInstallationToken
+
+	default:
+		return ""
+	}
 }
 
 func (ac *AuthConfig) GenerateJwt() (string, error) {
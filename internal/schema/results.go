@@ -0,0 +1,73 @@
+package schema
+
+import "time"
+
+// job statuses reported in JobResult
+var (
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+	JobStatusTimedOut  = "timedOut"
+)
+
+// JobResult describes the progress or outcome of a single job. It is reported when the job starts and again when it finishes.
+type JobResult struct {
+	JobID     string `json:"jobID"`
+	RunID     string `json:"runID"`
+	Platform  string `json:"platform"`
+	Repo      string `json:"repo"`
+	ChoreName string `json:"choreName"`
+	ChoreURL  string `json:"choreURL"`
+
+	Status     string    `json:"status"`
+	Attempts   int       `json:"attempts"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt,omitzero"`
+
+	// ExecutorJobName is the name of the executor's job for the most recent attempt.
+	ExecutorJobName string `json:"executorJobName,omitempty"`
+
+	// PullRequestNumber and PullRequestURL are set if the job opened or updated a PR. PullRequestCreated distinguishes between the two.
+	PullRequestNumber  int    `json:"pullRequestNumber,omitempty"`
+	PullRequestURL     string `json:"pullRequestURL,omitempty"`
+	PullRequestCreated bool   `json:"pullRequestCreated,omitempty"`
+
+	// these fields are only set for failed jobs
+	Error        string `json:"error,omitempty"`
+	FailureClass string `json:"failureClass,omitempty"`
+	FailedStep   string `json:"failedStep,omitempty"`
+	Logs         string `json:"logs,omitempty"`
+}
+
+// NewJobResult starts the result for a job that is about to run.
+func NewJobResult(job Job, runID string) JobResult {
+	return JobResult{
+		JobID:     job.ID,
+		RunID:     runID,
+		Platform:  job.PlatformConfig.BaseURL,
+		Repo:      job.Repo.FullName(),
+		ChoreName: job.Chore.Name,
+		ChoreURL:  job.Chore.SourceConfig.URL + "#" + job.Chore.SourceConfig.Directory,
+		Status:    JobStatusRunning,
+		StartedAt: time.Now(),
+	}
+}
+
+// Duration returns how long the job ran for, or has been running for so far.
+func (r JobResult) Duration() time.Duration {
+	if r.FinishedAt.IsZero() {
+		return time.Since(r.StartedAt)
+	}
+
+	return r.FinishedAt.Sub(r.StartedAt)
+}
+
+// TerminationMessagePath is where the finalise step writes its FinaliseResult, so the executor can read it back from the container status.
+const TerminationMessagePath = "/dev/termination-log"
+
+// FinaliseResult is reported by the finalise step of a chore.
+type FinaliseResult struct {
+	PullRequestNumber  int    `json:"pullRequestNumber,omitempty"`
+	PullRequestURL     string `json:"pullRequestURL,omitempty"`
+	PullRequestCreated bool   `json:"pullRequestCreated,omitempty"`
+}
@@ -0,0 +1,161 @@
+package schema
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/robfig/cron/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// ParseRepoConfig decodes a repo config file (i.e. `.tedium.yml` or an extended `index.yml`), rejecting unknown fields.
+func ParseRepoConfig(raw []byte) (RepoConfig, error) {
+	var repoConfig RepoConfig
+	decoder := yaml.NewDecoder(bytes.NewReader(raw))
+	decoder.KnownFields(true)
+	err := decoder.Decode(&repoConfig)
+	if err != nil {
+		return RepoConfig{}, fmt.Errorf("failed to unmarshal repo config file: %w", err)
+	}
+
+	return repoConfig, nil
+}
+
+// ParseChoreSpec decodes a chore definition file (i.e. `chore.yml`), rejecting unknown fields.
+func ParseChoreSpec(raw []byte) (ChoreSpec, error) {
+	var choreSpec ChoreSpec
+	decoder := yaml.NewDecoder(bytes.NewReader(raw))
+	decoder.KnownFields(true)
+	err := decoder.Decode(&choreSpec)
+	if err != nil {
+		return ChoreSpec{}, fmt.Errorf("failed to unmarshal chore config file: %w", err)
+	}
+
+	return choreSpec, nil
+}
+
+// Validate checks a repo config for mistakes that would otherwise only show up when chores run. It doesn't follow any URLs.
+func (rc RepoConfig) Validate() error {
+	var errs []error
+
+	for _, extendsURL := range rc.Extends {
+		_, err := RepoFromURL(extendsURL)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("extends %s: %w", extendsURL, err))
+		}
+	}
+
+	for i, chore := range rc.Chores {
+		for _, err := range chore.validate() {
+			errs = append(errs, fmt.Errorf("chores[%d]: %w", i, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (rcc RepoChoreConfig) validate() []error {
+	var errs []error
+
+	if rcc.URL == "" {
+		errs = append(errs, fmt.Errorf("url is required"))
+	} else {
+		_, err := RepoFromURL(rcc.URL)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("url %s: %w", rcc.URL, err))
+		}
+	}
+
+	if rcc.Directory == "" {
+		errs = append(errs, fmt.Errorf("directory is required"))
+	}
+
+	errs = append(errs, validateEnvironment(rcc.Environment)...)
+
+	err := validateSchedule(rcc.Schedule)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	return errs
+}
+
+// Validate checks a chore definition for mistakes that would otherwise only show up when it runs.
+func (choreSpec ChoreSpec) Validate() error {
+	var errs []error
+
+	if choreSpec.Name == "" {
+		errs = append(errs, fmt.Errorf("name is required"))
+	}
+
+	if len(choreSpec.Steps) == 0 {
+		errs = append(errs, fmt.Errorf("at least one step is required"))
+	}
+
+	for i, step := range choreSpec.Steps {
+		if step.Image == "" {
+			errs = append(errs, fmt.Errorf("steps[%d]: image is required", i))
+		}
+
+		if step.Command == "" {
+			errs = append(errs, fmt.Errorf("steps[%d]: command is required", i))
+		}
+
+		if step.TimeoutSeconds < 0 {
+			errs = append(errs, fmt.Errorf("steps[%d]: timeoutSeconds must not be negative", i))
+		}
+
+		for _, err := range validateEnvironment(step.Environment) {
+			errs = append(errs, fmt.Errorf("steps[%d]: %w", i, err))
+		}
+	}
+
+	if choreSpec.TimeoutSeconds < 0 {
+		errs = append(errs, fmt.Errorf("timeoutSeconds must not be negative"))
+	}
+
+	cacheNames := map[string]bool{}
+	for i, cache := range choreSpec.Caches {
+		if cache.Name == "" || cache.MountPath == "" {
+			errs = append(errs, fmt.Errorf("caches[%d]: name and mountPath are required", i))
+		}
+
+		if cacheNames[cache.Name] {
+			errs = append(errs, fmt.Errorf("caches[%d]: duplicate cache name %s", i, cache.Name))
+		}
+		cacheNames[cache.Name] = true
+	}
+
+	errs = append(errs, validateSchedule(choreSpec.Schedule))
+
+	return errors.Join(errs...)
+}
+
+// validateEnvironment rejects variables that would be dropped because they clash with Tedium's own.
+func validateEnvironment(env map[string]string) []error {
+	var errs []error
+	for _, k := range slices.Sorted(maps.Keys(env)) {
+		if strings.HasPrefix(k, "TEDIUM_") {
+			errs = append(errs, fmt.Errorf("environment variable %s must not start with TEDIUM_", k))
+		}
+	}
+
+	return errs
+}
+
+func validateSchedule(schedule string) error {
+	if schedule == "" {
+		return nil
+	}
+
+	_, err := cron.ParseStandard(schedule)
+	if err != nil {
+		return fmt.Errorf("invalid schedule %q: %w", schedule, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRepoConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr []string
+	}{
+		{
+			name: "valid",
+			raw: `
+extends:
+  - https://github.com/owner/shared
+chores:
+  - url: https://github.com/owner/chores
+    directory: lint
+    schedule: "0 3 * * *"
+    environment:
+      LEVEL: strict
+`,
+		},
+		{
+			name:    "unknown field",
+			raw:     "chores:\n  - url: https://github.com/owner/chores\n    directory: lint\n    schedul: daily\n",
+			wantErr: []string{"failed to unmarshal repo config file"},
+		},
+		{
+			name:    "invalid extends URL",
+			raw:     "extends:\n  - https://github.com/owner\n",
+			wantErr: []string{"extends https://github.com/owner"},
+		},
+		{
+			name:    "missing url and directory",
+			raw:     "chores:\n  - branch: main\n",
+			wantErr: []string{"chores[0]: url is required", "chores[0]: directory is required"},
+		},
+		{
+			name:    "invalid chore URL",
+			raw:     "chores:\n  - url: chores\n    directory: lint\n",
+			wantErr: []string{"chores[0]: url chores"},
+		},
+		{
+			name:    "reserved environment variable",
+			raw:     "chores:\n  - url: https://github.com/owner/chores\n    directory: lint\n    environment:\n      TEDIUM_JOB: x\n",
+			wantErr: []string{"chores[0]: environment variable TEDIUM_JOB must not start with TEDIUM_"},
+		},
+		{
+			name:    "invalid schedule",
+			raw:     "chores:\n  - url: https://github.com/owner/chores\n    directory: lint\n  - url: https://github.com/owner/chores\n    directory: fmt\n    schedule: sometimes\n",
+			wantErr: []string{`chores[1]: invalid schedule "sometimes"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoConfig, err := ParseRepoConfig([]byte(tt.raw))
+			if err == nil {
+				err = repoConfig.Validate()
+			}
+
+			checkValidationErr(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestChoreSpecValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr []string
+	}{
+		{
+			name: "valid",
+			raw: `
+name: lint
+schedule: "@daily"
+timeoutSeconds: 600
+steps:
+  - image: alpine
+    command: echo hello
+    timeoutSeconds: 60
+    environment:
+      LEVEL: strict
+caches:
+  - name: npm
+    mountPath: /root/.npm
+`,
+		},
+		{
+			name:    "unknown field",
+			raw:     "name: lint\nstep:\n  - image: alpine\n    command: echo hello\n",
+			wantErr: []string{"failed to unmarshal chore config file"},
+		},
+		{
+			name:    "missing name and steps",
+			raw:     "description: nothing to do\n",
+			wantErr: []string{"name is required", "at least one step is required"},
+		},
+		{
+			name:    "incomplete step",
+			raw:     "name: lint\nsteps:\n  - image: alpine\n  - command: echo hello\n",
+			wantErr: []string{"steps[0]: command is required", "steps[1]: image is required"},
+		},
+		{
+			name:    "negative timeouts",
+			raw:     "name: lint\ntimeoutSeconds: -1\nsteps:\n  - image: alpine\n    command: echo hello\n    timeoutSeconds: -1\n",
+			wantErr: []string{"steps[0]: timeoutSeconds must not be negative", "timeoutSeconds must not be negative"},
+		},
+		{
+			name:    "reserved environment variable",
+			raw:     "name: lint\nsteps:\n  - image: alpine\n    command: echo hello\n    environment:\n      TEDIUM_REPO: x\n",
+			wantErr: []string{"steps[0]: environment variable TEDIUM_REPO must not start with TEDIUM_"},
+		},
+		{
+			name:    "invalid caches",
+			raw:     "name: lint\nsteps:\n  - image: alpine\n    command: echo hello\ncaches:\n  - name: npm\n    mountPath: /a\n  - name: npm\n    mountPath: /b\n  - name: go\n",
+			wantErr: []string{"caches[1]: duplicate cache name npm", "caches[2]: name and mountPath are required"},
+		},
+		{
+			name:    "invalid schedule",
+			raw:     "name: lint\nschedule: \"* *\"\nsteps:\n  - image: alpine\n    command: echo hello\n",
+			wantErr: []string{`invalid schedule "* *"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			choreSpec, err := ParseChoreSpec([]byte(tt.raw))
+			if err == nil {
+				err = choreSpec.Validate()
+			}
+
+			checkValidationErr(t, err, tt.wantErr)
+		})
+	}
+}
+
+func checkValidationErr(t *testing.T, err error, wantErr []string) {
+	t.Helper()
+
+	if len(wantErr) == 0 {
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		return
+	}
+
+	if err == nil {
+		t.Fatalf("expected errors containing %q, got nil", wantErr)
+	}
+
+	for _, want := range wantErr {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err.Error(), want)
+		}
+	}
+}
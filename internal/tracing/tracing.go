@@ -0,0 +1,104 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/markormesher/tedium/internal/schema"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// shutdownTimeout limits how long we wait for buffered spans to be exported when Tedium exits.
+const shutdownTimeout = 10 * time.Second
+
+// tracer is backed by the global provider, so spans are no-ops until Init has been called with tracing enabled.
+var tracer = otel.Tracer("github.com/markormesher/tedium")
+
+var propagator = propagation.TraceContext{}
+
+// Init sets up span exporting if it is enabled in the config. The returned function flushes any buffered spans and must be called before Tedium exits.
+func Init(ctx context.Context, conf schema.TediumConfig) (func(), error) {
+	if conf.Tracing.OTLPEndpoint == "" {
+		return func() {}, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(conf.Tracing.OTLPEndpoint))
+	if err != nil {
+		return nil, fmt.Errorf("error creating trace exporter: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", "tedium"),
+			attribute.String("service.version", conf.Version),
+		)),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagator)
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		err := provider.Shutdown(ctx)
+		if err != nil {
+			slog.Warn("error flushing traces", "error", err)
+		}
+	}, nil
+}
+
+// Start starts a span as a child of any span in the context.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End ends a span, marking it as failed if there was an error.
+func End(span trace.Span, err error) {
+	SetError(span, err)
+	span.End()
+}
+
+// SetError marks a span as failed if there was an error.
+func SetError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// RecordSpan records a span for something that has already happened elsewhere, such as a step that ran in an executor.
+func RecordSpan(ctx context.Context, name string, start time.Time, end time.Time, err error, attrs ...attribute.KeyValue) {
+	_, span := tracer.Start(ctx, name, trace.WithTimestamp(start), trace.WithAttributes(attrs...))
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End(trace.WithTimestamp(end))
+}
+
+// TraceParent returns the W3C traceparent header value for the span in the context, or an empty string if it isn't being traced.
+func TraceParent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(ctx, carrier)
+	return carrier.Get("traceparent")
+}
+
+// ContextWithTraceParent returns a context whose spans will be children of the span described by a W3C traceparent header value.
+func ContextWithTraceParent(ctx context.Context, traceParent string) context.Context {
+	if traceParent == "" {
+		return ctx
+	}
+
+	return propagator.Extract(ctx, propagation.MapCarrier{"traceparent": traceParent})
+}
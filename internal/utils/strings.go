@@ -37,3 +37,15 @@ func ConvertToBranchName(value string) string {
 	value = strings.ToLower(value)
 	return "tedium/" + value
 }
+
+var illegalLabelValueCharRegex = regexp.MustCompile(`[^a-zA-Z0-9\-_.]`)
+
+// ConvertToLabelValue converts an arbitrary string into something that can be used as a Kubernetes label value. The conversion is lossy, so the original value should be stored elsewhere if it is needed.
+func ConvertToLabelValue(value string) string {
+	value = strings.ReplaceAll(value, "/", ".")
+	value = illegalLabelValueCharRegex.ReplaceAllString(value, "-")
+	if len(value) > 63 {
+		value = value[:63]
+	}
+	return strings.Trim(value, "-_.")
+}